@@ -0,0 +1,404 @@
+package article
+
+import (
+	"context"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+)
+
+// ==================== 访问控制 ====================
+//
+// 启用方式：WithAccessControl(grantRepo)。启用后每个服务方法都要求 context 中带有 Principal：
+//   - 管理员（Type=admin）拥有全部权限
+//   - 文章所有者（本人，或所有者为其所属团队）视为 owner
+//   - 其余按文章授权与所在文件夹（含上级，需注入 FolderAncestorResolver）授权取最高角色
+// 未启用时保持原有行为，不做任何校验。
+
+// FolderAncestorResolver 文件夹层级解析（由文件夹领域实现）
+type FolderAncestorResolver interface {
+	// AncestorIDs 返回文件夹的全部上级文件夹ID（不含自身）
+	AncestorIDs(ctx context.Context, folderID uint) ([]uint, error)
+}
+
+// WithAccessControl 启用文章访问控制
+func WithAccessControl(grantRepo ArticleGrantRepository) ServiceOption {
+	return func(s *Service) {
+		s.grantRepo = grantRepo
+	}
+}
+
+// WithFolderAncestorResolver 注入文件夹层级解析，使上级文件夹的授权可以继承
+func WithFolderAncestorResolver(r FolderAncestorResolver) ServiceOption {
+	return func(s *Service) {
+		s.folderResolver = r
+	}
+}
+
+// GrantInput 授权输入
+type GrantInput struct {
+	SubjectType string // user, team
+	SubjectID   uint
+	Role        string // viewer, commenter, editor, owner
+}
+
+// GrantArticleAccess 授予用户/团队对文章的角色（需要 owner）
+func (s *Service) GrantArticleAccess(ctx context.Context, articleID uint, input *GrantInput) error {
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.grant(ctx, model.ResourceTypeArticle, articleID, input)
+}
+
+// RevokeArticleAccess 撤销文章授权（需要 owner）
+func (s *Service) RevokeArticleAccess(ctx context.Context, articleID uint, subjectType string, subjectID uint) error {
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.revoke(ctx, model.ResourceTypeArticle, articleID, subjectType, subjectID)
+}
+
+// ListArticleGrants 查询文章上的授权（需要 owner）
+func (s *Service) ListArticleGrants(ctx context.Context, articleID uint) ([]model.ArticleGrant, error) {
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.listGrants(ctx, model.ResourceTypeArticle, articleID)
+}
+
+// GrantFolderAccess 授予用户/团队对文件夹的角色，文件夹内文章继承该角色
+// 需要管理员或在该文件夹（含上级）上拥有 owner 授权；文件夹创建者的初始授权由应用层以管理员身份写入。
+func (s *Service) GrantFolderAccess(ctx context.Context, folderID uint, input *GrantInput) error {
+	if err := s.authorizeFolder(ctx, folderID, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.grant(ctx, model.ResourceTypeFolder, folderID, input)
+}
+
+// RevokeFolderAccess 撤销文件夹授权
+func (s *Service) RevokeFolderAccess(ctx context.Context, folderID uint, subjectType string, subjectID uint) error {
+	if err := s.authorizeFolder(ctx, folderID, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.revoke(ctx, model.ResourceTypeFolder, folderID, subjectType, subjectID)
+}
+
+// ListFolderGrants 查询文件夹上的授权
+func (s *Service) ListFolderGrants(ctx context.Context, folderID uint) ([]model.ArticleGrant, error) {
+	if err := s.authorizeFolder(ctx, folderID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.listGrants(ctx, model.ResourceTypeFolder, folderID)
+}
+
+// GetArticleRole 获取当前主体对文章的有效角色（未授权时返回空字符串）
+func (s *Service) GetArticleRole(ctx context.Context, articleID uint) (string, error) {
+	article, err := s.findArticle(ctx, articleID)
+	if err != nil {
+		return "", err
+	}
+	if !s.aclEnabled() {
+		return model.RoleOwner, nil
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	return s.effectiveRole(ctx, p, article)
+}
+
+func (s *Service) grant(ctx context.Context, resourceType string, resourceID uint, input *GrantInput) error {
+	if !s.aclEnabled() {
+		return ErrBadRequest.WithMsg("未启用访问控制")
+	}
+	if input.SubjectType != model.SubjectTypeUser && input.SubjectType != model.SubjectTypeTeam {
+		return ErrBadRequest.WithMsgf("不支持的授权对象类型: %s", input.SubjectType)
+	}
+	if model.RoleRank(input.Role) == 0 {
+		return ErrBadRequest.WithMsgf("不支持的角色: %s", input.Role)
+	}
+
+	grant := &model.ArticleGrant{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		SubjectType:  input.SubjectType,
+		SubjectID:    input.SubjectID,
+		Role:         input.Role,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		grant.GrantedBy = p.ID
+	}
+	if err := s.grantRepo.Upsert(ctx, grant); err != nil {
+		s.logger.ErrorCtx(ctx, "授权失败", zap.String("resource_type", resourceType), zap.Uint("resource_id", resourceID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	s.logger.InfoCtx(ctx, "授权成功",
+		zap.String("resource_type", resourceType), zap.Uint("resource_id", resourceID),
+		zap.String("subject_type", input.SubjectType), zap.Uint("subject_id", input.SubjectID), zap.String("role", input.Role))
+	return nil
+}
+
+func (s *Service) revoke(ctx context.Context, resourceType string, resourceID uint, subjectType string, subjectID uint) error {
+	if !s.aclEnabled() {
+		return ErrBadRequest.WithMsg("未启用访问控制")
+	}
+	if err := s.grantRepo.Delete(ctx, resourceType, resourceID, subjectType, subjectID); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) listGrants(ctx context.Context, resourceType string, resourceID uint) ([]model.ArticleGrant, error) {
+	if !s.aclEnabled() {
+		return nil, ErrBadRequest.WithMsg("未启用访问控制")
+	}
+	grants, err := s.grantRepo.FindByResource(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return grants, nil
+}
+
+// ==================== 权限校验 ====================
+
+func (s *Service) aclEnabled() bool {
+	return s.grantRepo != nil
+}
+
+// getArticleFor 获取文章并校验当前主体至少拥有 minRole
+func (s *Service) getArticleFor(ctx context.Context, id uint, minRole string) (*model.Article, error) {
+	article, err := s.findArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, article, minRole); err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// requirePrincipal 启用访问控制时要求已登录
+func (s *Service) requirePrincipal(ctx context.Context) (*Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !s.aclEnabled() {
+		return p, nil
+	}
+	if !ok {
+		return nil, ErrForbidden.WithMsg("未登录")
+	}
+	return p, nil
+}
+
+// authorize 校验当前主体对文章至少拥有 minRole
+func (s *Service) authorize(ctx context.Context, article *model.Article, minRole string) error {
+	if !s.aclEnabled() || hasSystemAccess(ctx) {
+		return nil
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrForbidden.WithMsg("未登录")
+	}
+
+	role, err := s.effectiveRole(ctx, p, article)
+	if err != nil {
+		return err
+	}
+	if model.RoleRank(role) < model.RoleRank(minRole) {
+		return ErrForbidden.WithMsgf("需要 %s 权限", minRole)
+	}
+	return nil
+}
+
+// authorizeOwnerScope 校验当前主体能否代表指定所有者操作（创建文章、管理标签等）
+func (s *Service) authorizeOwnerScope(ctx context.Context, ownerType string, ownerID uint) error {
+	p, err := s.requirePrincipal(ctx)
+	if err != nil || p == nil || !s.aclEnabled() || p.IsAdmin() {
+		return err
+	}
+	if isOwner(p, &model.Article{OwnerType: ownerType, OwnerID: ownerID}) {
+		return nil
+	}
+	return ErrForbidden.WithMsg("不能以其他所有者身份操作")
+}
+
+// authorizeFolder 校验当前主体对文件夹（含上级）至少拥有 minRole
+func (s *Service) authorizeFolder(ctx context.Context, folderID uint, minRole string) error {
+	if !s.aclEnabled() {
+		return nil
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrForbidden.WithMsg("未登录")
+	}
+	if p.IsAdmin() {
+		return nil
+	}
+
+	folderIDs, err := s.folderChain(ctx, folderID)
+	if err != nil {
+		return err
+	}
+	grants, err := s.grantRepo.FindForPrincipal(ctx, model.ResourceTypeFolder, folderIDs, p.ID, p.TeamIDs)
+	if err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	if model.RoleRank(highestRole(grants)) < model.RoleRank(minRole) {
+		return ErrForbidden.WithMsgf("需要文件夹 %s 权限", minRole)
+	}
+	return nil
+}
+
+// authorizeMove 校验当前主体能否把文章移到目标文件夹
+// 文件夹授权会被继承，因此需要文章 owner 且对目标文件夹至少拥有 editor，避免借移动获得更高权限；文件夹不变时不校验。
+func (s *Service) authorizeMove(ctx context.Context, article *model.Article, folderID *uint) error {
+	if equalFolderID(article.FolderID, folderID) {
+		return nil
+	}
+	if err := s.authorize(ctx, article, model.RoleOwner); err != nil {
+		return err
+	}
+	if folderID == nil {
+		return nil
+	}
+	return s.authorizeFolder(ctx, *folderID, model.RoleEditor)
+}
+
+// effectiveRole 计算主体对文章的有效角色
+func (s *Service) effectiveRole(ctx context.Context, p *Principal, article *model.Article) (string, error) {
+	if p.IsAdmin() || isOwner(p, article) {
+		return model.RoleOwner, nil
+	}
+
+	grants, err := s.grantRepo.FindForPrincipal(ctx, model.ResourceTypeArticle, []uint{article.ID}, p.ID, p.TeamIDs)
+	if err != nil {
+		return "", ErrDatabaseError.Wrap(err)
+	}
+	role := highestRole(grants)
+
+	if article.FolderID != nil {
+		folderIDs, err := s.folderChain(ctx, *article.FolderID)
+		if err != nil {
+			return "", err
+		}
+		folderGrants, err := s.grantRepo.FindForPrincipal(ctx, model.ResourceTypeFolder, folderIDs, p.ID, p.TeamIDs)
+		if err != nil {
+			return "", ErrDatabaseError.Wrap(err)
+		}
+		if r := highestRole(folderGrants); model.RoleRank(r) > model.RoleRank(role) {
+			role = r
+		}
+	}
+	return role, nil
+}
+
+// restrictVisible 启用访问控制时把列表查询限定为当前主体可见的文章
+// 列表查询只继承文章直属文件夹的授权；上级文件夹的继承仅在单篇校验中生效。
+func (s *Service) restrictVisible(ctx context.Context, q *ArticleQuery) error {
+	p, err := s.requirePrincipal(ctx)
+	if err != nil || !s.aclEnabled() {
+		return err
+	}
+	q.VisibleTo = p
+	return nil
+}
+
+// filterVisible 过滤出当前主体可查看的文章（批量查询授权）
+func (s *Service) filterVisible(ctx context.Context, articles []model.Article) ([]model.Article, error) {
+	if !s.aclEnabled() {
+		return articles, nil
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden.WithMsg("未登录")
+	}
+	if p.IsAdmin() {
+		return articles, nil
+	}
+
+	var articleIDs []uint
+	chains := make(map[uint][]uint) // folderID -> 自身及上级
+	var folderIDs []uint
+	for _, a := range articles {
+		articleIDs = append(articleIDs, a.ID)
+		if a.FolderID != nil {
+			if _, ok := chains[*a.FolderID]; !ok {
+				chain, err := s.folderChain(ctx, *a.FolderID)
+				if err != nil {
+					return nil, err
+				}
+				chains[*a.FolderID] = chain
+				folderIDs = append(folderIDs, chain...)
+			}
+		}
+	}
+
+	articleGrants, err := s.grantRepo.FindForPrincipal(ctx, model.ResourceTypeArticle, articleIDs, p.ID, p.TeamIDs)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	folderGrants, err := s.grantRepo.FindForPrincipal(ctx, model.ResourceTypeFolder, folderIDs, p.ID, p.TeamIDs)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	grantedArticles := make(map[uint]bool, len(articleGrants))
+	for _, g := range articleGrants {
+		grantedArticles[g.ResourceID] = true
+	}
+	grantedFolders := make(map[uint]bool, len(folderGrants))
+	for _, g := range folderGrants {
+		grantedFolders[g.ResourceID] = true
+	}
+
+	visible := make([]model.Article, 0, len(articles))
+	for _, a := range articles {
+		ok := isOwner(p, &a) || grantedArticles[a.ID]
+		if !ok && a.FolderID != nil {
+			for _, id := range chains[*a.FolderID] {
+				if grantedFolders[id] {
+					ok = true
+					break
+				}
+			}
+		}
+		if ok {
+			visible = append(visible, a)
+		}
+	}
+	return visible, nil
+}
+
+// folderChain 返回文件夹自身及其全部上级
+func (s *Service) folderChain(ctx context.Context, folderID uint) ([]uint, error) {
+	chain := []uint{folderID}
+	if s.folderResolver == nil {
+		return chain, nil
+	}
+	ancestors, err := s.folderResolver.AncestorIDs(ctx, folderID)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "解析文件夹层级失败", zap.Uint("folder_id", folderID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return append(chain, ancestors...), nil
+}
+
+// isOwner 主体是否为文章所有者（本人或所属团队）
+func isOwner(p *Principal, article *model.Article) bool {
+	if article.OwnerType == model.OwnerTypeTeam {
+		return p.InTeam(article.OwnerID)
+	}
+	return article.OwnerType == p.Type && article.OwnerID == p.ID
+}
+
+func highestRole(grants []model.ArticleGrant) string {
+	role := ""
+	for _, g := range grants {
+		if model.RoleRank(g.Role) > model.RoleRank(role) {
+			role = g.Role
+		}
+	}
+	return role
+}
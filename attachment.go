@@ -0,0 +1,377 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 附件 ====================
+
+// AttachmentLimits 附件限制
+type AttachmentLimits struct {
+	MaxSize      int64    // 单个附件最大字节数
+	AllowedTypes []string // 允许的 MIME 类型（按内容嗅探结果判断），支持 "image/*" 形式的通配
+}
+
+// DefaultAttachmentLimits 默认限制：20MB，常见图片、PDF、文本与 Office 文档
+// SVG 可内嵌脚本，默认不允许。
+func DefaultAttachmentLimits() AttachmentLimits {
+	return AttachmentLimits{
+		MaxSize: 20 << 20,
+		AllowedTypes: []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp",
+			"application/pdf",
+			"text/plain", "text/csv", "text/markdown",
+			"application/zip",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		},
+	}
+}
+
+// allows 是否允许该类型
+func (l AttachmentLimits) allows(contentType string) bool {
+	for _, t := range l.AllowedTypes {
+		if t == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadMaxAttempts 与相同内容的并发上传或删除冲突时的最大尝试次数
+const uploadMaxAttempts = 3
+
+// errBlobConflict Blob 记录与并发操作冲突，由 UploadAttachment 重试：
+// 写入失败（相同内容的并发上传已写入），或查到的 Blob 在增加引用前已被删除
+var errBlobConflict = errors.New("article: blob changed concurrently")
+
+// WithAttachmentStorage 注入附件仓储与内容存储，启用附件
+func WithAttachmentStorage(repo ArticleAttachmentRepository, store BlobStore) ServiceOption {
+	return func(s *Service) {
+		s.attachmentRepo = repo
+		s.blobStore = store
+	}
+}
+
+// WithAttachmentLimits 设置附件大小与类型限制
+func WithAttachmentLimits(limits AttachmentLimits) ServiceOption {
+	return func(s *Service) {
+		s.attachmentLimits = limits
+	}
+}
+
+// UploadAttachment 上传附件（需要 editor）
+// 类型以内容嗅探为准而不是客户端声明；相同内容的附件共享同一个 Blob。
+func (s *Service) UploadAttachment(ctx context.Context, articleID uint, fileName string, r io.Reader) (*model.ArticleAttachment, error) {
+	if err := s.requireAttachments(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	fileName, err := normalizeFileName(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多读 1 字节用于判断是否超限
+	data, err := io.ReadAll(io.LimitReader(r, s.attachmentLimits.MaxSize+1))
+	if err != nil {
+		return nil, ErrBadRequest.WithMsg("读取附件失败").Wrap(err)
+	}
+	if len(data) == 0 {
+		return nil, ErrBadRequest.WithMsg("附件不能为空")
+	}
+	if int64(len(data)) > s.attachmentLimits.MaxSize {
+		return nil, ErrBadRequest.WithMsgf("附件大小不能超过 %d 字节", s.attachmentLimits.MaxSize)
+	}
+	contentType := sniffContentType(data, fileName)
+	if !s.attachmentLimits.allows(contentType) {
+		return nil, ErrBadRequest.WithMsgf("不支持的附件类型: %s", contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	attachment := &model.ArticleAttachment{
+		ArticleID:   articleID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreatedAt:   time.Now(),
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		attachment.UploadedBy = p.ID
+	}
+
+	var stored bool // 本次是否写入过存储，失败时据此清理
+	for attempt := 1; ; attempt++ {
+		err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+			blob, err := repos.Attachment.FindBlobByHash(ctx, hash)
+			switch {
+			case err == nil:
+				if err := repos.Attachment.AddBlobRef(ctx, blob.ID, 1); err != nil {
+					if errors.Is(err, ErrStaleVersion) {
+						return errors.Join(errBlobConflict, err)
+					}
+					return ErrDatabaseError.Wrap(err)
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				blob = &model.ArticleBlob{
+					Hash:        hash,
+					Size:        int64(len(data)),
+					ContentType: contentType,
+					StorageKey:  blobKey(hash),
+					RefCount:    1,
+					CreatedAt:   time.Now(),
+					UpdatedAt:   time.Now(),
+				}
+				if err := s.blobStore.Put(ctx, blob.StorageKey, bytes.NewReader(data)); err != nil {
+					return ErrDatabaseError.Wrap(err)
+				}
+				stored = true
+				if err := repos.Attachment.CreateBlob(ctx, blob); err != nil {
+					return errors.Join(errBlobConflict, err)
+				}
+			default:
+				return ErrDatabaseError.Wrap(err)
+			}
+
+			attachment.BlobID = blob.ID
+			if err := repos.Attachment.Create(ctx, attachment); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+			return nil
+		})
+		// 相同内容的并发上传已先写入 Blob（hash 唯一）时复用对方的 Blob；复用的 Blob 已被删除时重新写入
+		if !errors.Is(err, errBlobConflict) || attempt == uploadMaxAttempts {
+			break
+		}
+	}
+	if errors.Is(err, errBlobConflict) {
+		err = ErrDatabaseError.Wrap(err)
+	}
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "上传附件失败", zap.Uint("article_id", articleID), zap.Error(err))
+		if stored {
+			s.deleteUnreferencedBlob(ctx, hash)
+		}
+		return nil, err
+	}
+
+	s.logger.InfoCtx(ctx, "附件上传成功", zap.Uint("article_id", articleID), zap.Uint("attachment_id", attachment.ID), zap.String("content_type", contentType))
+	return attachment, nil
+}
+
+// ListAttachments 查询文章的全部附件
+func (s *Service) ListAttachments(ctx context.Context, articleID uint) ([]model.ArticleAttachment, error) {
+	if err := s.requireAttachments(); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetArticle(ctx, articleID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return attachments, nil
+}
+
+// OpenAttachment 读取附件内容，调用方负责关闭返回的 ReadCloser
+func (s *Service) OpenAttachment(ctx context.Context, attachmentID uint) (*model.ArticleAttachment, io.ReadCloser, error) {
+	attachment, err := s.getAttachmentFor(ctx, attachmentID, model.RoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := s.attachmentRepo.FindBlobByID(ctx, attachment.BlobID)
+	if err != nil {
+		return nil, nil, ErrDatabaseError.Wrap(err)
+	}
+	rc, err := s.blobStore.Open(ctx, blob.StorageKey)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "读取附件内容失败", zap.Uint("attachment_id", attachmentID), zap.Error(err))
+		return nil, nil, ErrDatabaseError.Wrap(err)
+	}
+	return attachment, rc, nil
+}
+
+// DeleteAttachment 删除附件（需要 editor），Blob 不再被引用时一并删除
+func (s *Service) DeleteAttachment(ctx context.Context, attachmentID uint) error {
+	attachment, err := s.getAttachmentFor(ctx, attachmentID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	var orphanKeys []string
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		if err := repos.Attachment.Delete(ctx, attachment.ID); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		var err error
+		orphanKeys, err = releaseBlobs(ctx, repos, []model.ArticleAttachment{*attachment})
+		return err
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "删除附件失败", zap.Uint("attachment_id", attachmentID), zap.Error(err))
+		return err
+	}
+
+	s.deleteBlobs(ctx, orphanKeys)
+	return nil
+}
+
+// releaseArticleAttachments 删除文章的全部附件记录，返回已无引用、需要从存储删除的 key
+// 未启用附件时直接返回。
+func releaseArticleAttachments(ctx context.Context, repos *Repositories, articleID uint) ([]string, error) {
+	if repos.Attachment == nil {
+		return nil, nil
+	}
+	attachments, err := repos.Attachment.FindByArticleID(ctx, articleID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	if err := repos.Attachment.DeleteByArticleID(ctx, articleID); err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return releaseBlobs(ctx, repos, attachments)
+}
+
+// releaseBlobs 递减附件所引用 Blob 的计数，返回计数归零并已删除记录的存储 key
+func releaseBlobs(ctx context.Context, repos *Repositories, attachments []model.ArticleAttachment) ([]string, error) {
+	refs := make(map[uint]int)
+	for _, a := range attachments {
+		refs[a.BlobID]++
+	}
+
+	var orphanKeys []string
+	for blobID, n := range refs {
+		if err := repos.Attachment.AddBlobRef(ctx, blobID, -n); err != nil {
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+		blob, err := repos.Attachment.FindBlobByID(ctx, blobID)
+		if err != nil {
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+		deleted, err := repos.Attachment.DeleteBlobIfUnreferenced(ctx, blobID)
+		if err != nil {
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+		if deleted {
+			orphanKeys = append(orphanKeys, blob.StorageKey)
+		}
+	}
+	return orphanKeys, nil
+}
+
+// deleteBlobs 在事务提交后删除存储中的内容；失败只记录日志（残留文件不影响数据一致性）
+func (s *Service) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			s.logger.ErrorCtx(ctx, "删除附件内容失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// deleteUnreferencedBlob 上传失败后清理写入的内容
+// 存储 key 由内容 hash 决定，已有 Blob 记录（如并发上传的相同内容）时该文件仍被引用，不能删除。
+func (s *Service) deleteUnreferencedBlob(ctx context.Context, hash string) {
+	_, err := s.attachmentRepo.FindBlobByHash(ctx, hash)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.ErrorCtx(ctx, "查询附件内容失败", zap.String("hash", hash), zap.Error(err))
+		return
+	}
+	s.deleteBlobs(ctx, []string{blobKey(hash)})
+}
+
+func (s *Service) getAttachmentFor(ctx context.Context, attachmentID uint, minRole string) (*model.ArticleAttachment, error) {
+	if err := s.requireAttachments(); err != nil {
+		return nil, err
+	}
+	attachment, err := s.attachmentRepo.FindByID(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("附件不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if _, err := s.getArticleFor(ctx, attachment.ArticleID, minRole); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *Service) requireAttachments() error {
+	if s.attachmentRepo == nil || s.blobStore == nil {
+		return ErrBadRequest.WithMsg("未启用附件")
+	}
+	return nil
+}
+
+// refinedContentTypes 嗅探结果过于笼统时，按扩展名细化（仅限无害的类型）
+var refinedContentTypes = map[string]map[string]string{
+	"application/zip": {
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	},
+	"text/plain": {
+		".csv": "text/csv",
+		".md":  "text/markdown",
+	},
+}
+
+// sniffContentType 按内容嗅探 MIME 类型（不含 charset 等参数）
+func sniffContentType(data []byte, fileName string) string {
+	contentType := http.DetectContentType(data)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if refined, ok := refinedContentTypes[contentType][strings.ToLower(filepath.Ext(fileName))]; ok {
+		return refined
+	}
+	return contentType
+}
+
+// normalizeFileName 去掉路径部分，只保留文件名
+func normalizeFileName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return "", ErrBadRequest.WithMsg("文件名不能为空")
+	}
+	if len(name) > 255 {
+		return "", ErrBadRequest.WithMsg("文件名过长")
+	}
+	return name, nil
+}
+
+// blobKey 按哈希分目录存放，避免单目录文件过多
+func blobKey(hash string) string {
+	return hash[:2] + "/" + hash[2:4] + "/" + hash
+}
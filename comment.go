@@ -0,0 +1,337 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 评论 ====================
+
+// maxCommentLength 评论最大长度（字符）
+const maxCommentLength = 10000
+
+// WithCommentRepository 注入评论仓储，启用文章评论
+func WithCommentRepository(r ArticleCommentRepository) ServiceOption {
+	return func(s *Service) {
+		s.commentRepo = r
+	}
+}
+
+// AddCommentInput 发表评论输入
+type AddCommentInput struct {
+	Content  string
+	ParentID *uint // 回复的评论，为空表示发起新的讨论串
+}
+
+// AddComment 发表评论或回复（需要 commenter，作者取自 context 中的 Principal）
+func (s *Service) AddComment(ctx context.Context, articleID uint, input *AddCommentInput) (*model.ArticleComment, error) {
+	if err := s.requireCommentRepo(); err != nil {
+		return nil, err
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden.WithMsg("未登录")
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleCommenter); err != nil {
+		return nil, err
+	}
+	content, err := normalizeCommentContent(input.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.ArticleComment{
+		ArticleID:  articleID,
+		AuthorID:   p.ID,
+		AuthorType: p.Type,
+		Content:    content,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if input.ParentID != nil {
+		parent, err := s.findComment(ctx, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ArticleID != articleID {
+			return nil, ErrBadRequest.WithMsg("回复的评论不属于该文章")
+		}
+		if parent.IsDeleted() {
+			return nil, ErrBadRequest.WithMsg("不能回复已删除的评论")
+		}
+		rootID := parent.ID
+		if parent.RootID != nil {
+			rootID = *parent.RootID
+		}
+		comment.ParentID = &parent.ID
+		comment.RootID = &rootID
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		s.logger.ErrorCtx(ctx, "发表评论失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	s.dispatchAsync(ctx, NewArticleCommentCreatedEvent(articleID, comment.ID, comment.ParentID, comment.AuthorID))
+	return comment, nil
+}
+
+// EditComment 编辑评论（仅作者或管理员），编辑前的内容写入编辑历史
+func (s *Service) EditComment(ctx context.Context, commentID uint, content string) (*model.ArticleComment, error) {
+	comment, err := s.getActiveComment(ctx, commentID, model.RoleCommenter)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || !(p.IsAdmin() || isCommentAuthor(p, comment)) {
+		return nil, ErrForbidden.WithMsg("只能编辑自己的评论")
+	}
+	content, err = normalizeCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if content == comment.Content {
+		return comment, nil
+	}
+
+	now := time.Now()
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		edit := &model.ArticleCommentEdit{
+			CommentID:  comment.ID,
+			Content:    comment.Content,
+			EditorID:   p.ID,
+			EditorType: p.Type,
+			CreatedAt:  now,
+		}
+		if err := repos.Comment.CreateEdit(ctx, edit); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		comment.Content = content
+		comment.EditCount++
+		comment.EditedAt = &now
+		comment.UpdatedAt = now
+		if err := repos.Comment.Update(ctx, comment); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "编辑评论失败", zap.Uint("comment_id", commentID), zap.Error(err))
+		return nil, err
+	}
+
+	s.dispatchAsync(ctx, NewArticleCommentUpdatedEvent(comment.ArticleID, comment.ID))
+	return comment, nil
+}
+
+// DeleteComment 删除评论（软删除，作者或文章 owner 可删除）
+// 删除后保留在讨论串中，内容不再返回，回复不受影响。
+func (s *Service) DeleteComment(ctx context.Context, commentID uint) error {
+	comment, err := s.getActiveComment(ctx, commentID, model.RoleViewer)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeCommentAction(ctx, comment, model.RoleOwner); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	comment.DeletedAt = &now
+	comment.UpdatedAt = now
+	if err := s.commentRepo.Update(ctx, comment); err != nil {
+		s.logger.ErrorCtx(ctx, "删除评论失败", zap.Uint("comment_id", commentID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	s.dispatchAsync(ctx, NewArticleCommentDeletedEvent(comment.ArticleID, comment.ID))
+	return nil
+}
+
+// ResolveCommentThread 标记讨论串已解决或重新打开（讨论串发起人或 editor 可操作）
+func (s *Service) ResolveCommentThread(ctx context.Context, commentID uint, resolved bool) error {
+	comment, err := s.getActiveComment(ctx, commentID, model.RoleViewer)
+	if err != nil {
+		return err
+	}
+	if !comment.IsRoot() {
+		return ErrBadRequest.WithMsg("只能对讨论串的顶层评论操作")
+	}
+	if err := s.authorizeCommentAction(ctx, comment, model.RoleEditor); err != nil {
+		return err
+	}
+	if comment.IsResolved() == resolved {
+		return nil
+	}
+
+	now := time.Now()
+	if resolved {
+		comment.ResolvedAt = &now
+		if p, ok := PrincipalFromContext(ctx); ok {
+			comment.ResolvedBy = &p.ID
+		}
+	} else {
+		comment.ResolvedAt = nil
+		comment.ResolvedBy = nil
+	}
+	comment.UpdatedAt = now
+	if err := s.commentRepo.Update(ctx, comment); err != nil {
+		s.logger.ErrorCtx(ctx, "更新讨论串状态失败", zap.Uint("comment_id", commentID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	s.dispatchAsync(ctx, NewArticleCommentResolvedEvent(comment.ArticleID, comment.ID, resolved))
+	return nil
+}
+
+// CommentThread 讨论串：顶层评论及其全部回复（回复按时间正序，通过 ParentID 还原层级）
+type CommentThread struct {
+	Comment model.ArticleComment   `json:"comment"`
+	Replies []model.ArticleComment `json:"replies"`
+}
+
+// CommentPageResult 评论分页结果（按讨论串分页）
+type CommentPageResult struct {
+	Records []CommentThread `json:"records"`
+	Total   int64           `json:"total"`
+	Size    int             `json:"size"`
+	Current int             `json:"current"`
+}
+
+// ListComments 分页查询文章的讨论串（需要 viewer）
+func (s *Service) ListComments(ctx context.Context, articleID uint, page, size int) (*CommentPageResult, error) {
+	if err := s.requireCommentRepo(); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetArticle(ctx, articleID); err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > MaxPageSize {
+		size = DefaultPageSize
+	}
+
+	roots, total, err := s.commentRepo.PaginateRoots(ctx, articleID, page, size)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "查询评论失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	rootIDs := make([]uint, len(roots))
+	for i, c := range roots {
+		rootIDs[i] = c.ID
+	}
+	replies, err := s.commentRepo.FindByRootIDs(ctx, rootIDs)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	byRoot := make(map[uint][]model.ArticleComment, len(roots))
+	for _, r := range replies {
+		byRoot[*r.RootID] = append(byRoot[*r.RootID], redactDeletedComment(r))
+	}
+	threads := make([]CommentThread, len(roots))
+	for i, root := range roots {
+		threads[i] = CommentThread{Comment: redactDeletedComment(root), Replies: byRoot[root.ID]}
+	}
+
+	return &CommentPageResult{
+		Records: threads,
+		Total:   total,
+		Size:    size,
+		Current: page,
+	}, nil
+}
+
+// ListCommentEdits 查询评论的编辑历史（按时间倒序）
+func (s *Service) ListCommentEdits(ctx context.Context, commentID uint) ([]model.ArticleCommentEdit, error) {
+	if _, err := s.getActiveComment(ctx, commentID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	edits, err := s.commentRepo.FindEdits(ctx, commentID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return edits, nil
+}
+
+// getActiveComment 获取未删除的评论，并校验当前主体对所属文章至少拥有 minRole
+func (s *Service) getActiveComment(ctx context.Context, commentID uint, minRole string) (*model.ArticleComment, error) {
+	if err := s.requireCommentRepo(); err != nil {
+		return nil, err
+	}
+	comment, err := s.findComment(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted() {
+		return nil, ErrDeleted.WithMsg("评论已删除")
+	}
+	if _, err := s.getArticleFor(ctx, comment.ArticleID, minRole); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (s *Service) findComment(ctx context.Context, commentID uint) (*model.ArticleComment, error) {
+	comment, err := s.commentRepo.FindByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("评论不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return comment, nil
+}
+
+// authorizeCommentAction 评论作者可直接操作，其他人需要对文章拥有 minRole
+func (s *Service) authorizeCommentAction(ctx context.Context, comment *model.ArticleComment, minRole string) error {
+	if p, ok := PrincipalFromContext(ctx); ok && isCommentAuthor(p, comment) {
+		return nil
+	}
+	article, err := s.findArticle(ctx, comment.ArticleID)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, article, minRole)
+}
+
+func (s *Service) requireCommentRepo() error {
+	if s.commentRepo == nil {
+		return ErrBadRequest.WithMsg("未启用评论")
+	}
+	return nil
+}
+
+func isCommentAuthor(p *Principal, comment *model.ArticleComment) bool {
+	return comment.AuthorType == p.Type && comment.AuthorID == p.ID
+}
+
+// redactDeletedComment 已删除的评论只保留结构信息
+func redactDeletedComment(c model.ArticleComment) model.ArticleComment {
+	if c.IsDeleted() {
+		c.Content = ""
+	}
+	return c
+}
+
+// normalizeCommentContent 去除首尾空白并校验长度
+func normalizeCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", ErrBadRequest.WithMsg("评论内容不能为空")
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return "", ErrBadRequest.WithMsgf("评论内容不能超过 %d 个字符", maxCommentLength)
+	}
+	return content, nil
+}
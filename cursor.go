@@ -0,0 +1,111 @@
+package article
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+)
+
+// ArticleCursor 文章列表游标（按 created_at DESC, id DESC 排序的位置）
+type ArticleCursor struct {
+	CreatedAt time.Time
+	ID        uint
+	Backward  bool // true 表示从该位置向前（更新的文章）翻页
+}
+
+type cursorPayload struct {
+	T int64 `json:"t"`
+	I uint  `json:"i"`
+	B bool  `json:"b,omitempty"`
+}
+
+// Encode 编码为不透明字符串
+func (c *ArticleCursor) Encode() string {
+	data, _ := json.Marshal(cursorPayload{T: c.CreatedAt.UnixNano(), I: c.ID, B: c.Backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeArticleCursor 解析游标字符串
+func DecodeArticleCursor(s string) (*ArticleCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var p cursorPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &ArticleCursor{CreatedAt: time.Unix(0, p.T), ID: p.I, Backward: p.B}, nil
+}
+
+// CursorPageResult 游标分页结果
+type CursorPageResult struct {
+	Records     []model.Article `json:"records"`
+	Size        int             `json:"size"`
+	NextCursor  string          `json:"nextCursor"` // 为空表示没有更多
+	PrevCursor  string          `json:"prevCursor"` // 为空表示已在最前
+	HasNext     bool            `json:"hasNext"`
+	HasPrevious bool            `json:"hasPrevious"`
+}
+
+// ListArticlesCursor 游标分页查询文章（按创建时间倒序）
+// 适合无限滚动场景：翻页期间新建的文章不会导致重复或遗漏；管理后台仍可使用 ListArticles。
+// cursor 为空表示第一页，之后传入上一页返回的 NextCursor / PrevCursor。
+// q 的排序与页码字段会被忽略，PageSize 作为每页数量。
+func (s *Service) ListArticlesCursor(ctx context.Context, q *ArticleQuery, cursor string) (*CursorPageResult, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.restrictVisible(ctx, q); err != nil {
+		return nil, err
+	}
+	size := q.PageSize
+
+	var c *ArticleCursor
+	if cursor != "" {
+		var err error
+		if c, err = DecodeArticleCursor(cursor); err != nil {
+			return nil, ErrBadRequest.WithMsg("无效的游标")
+		}
+	}
+
+	// 多取一条用于判断是否还有更多
+	articles, err := s.articleRepo.ListByCursor(ctx, q, c, size+1)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "游标查询文章列表失败", zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	more := len(articles) > size
+	if more {
+		if c != nil && c.Backward {
+			articles = articles[1:] // 向前翻页时多取的一条在最前面
+		} else {
+			articles = articles[:size]
+		}
+	}
+
+	result := &CursorPageResult{Records: articles, Size: size}
+	if c != nil && c.Backward {
+		result.HasPrevious = more
+		result.HasNext = true
+	} else {
+		result.HasNext = more
+		result.HasPrevious = c != nil
+	}
+
+	if len(articles) > 0 {
+		first, last := articles[0], articles[len(articles)-1]
+		if result.HasNext {
+			result.NextCursor = (&ArticleCursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+		}
+		if result.HasPrevious {
+			result.PrevCursor = (&ArticleCursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true}).Encode()
+		}
+	}
+	return result, nil
+}
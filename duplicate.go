@@ -0,0 +1,211 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 复制文章 ====================
+
+// DuplicateArticleOptions 复制文章选项
+type DuplicateArticleOptions struct {
+	Title     string // 为空时为 "原标题 副本"
+	FolderID  **uint // 二级指针：nil=原文件夹, *nil=不放入文件夹, *value=指定文件夹
+	OwnerID   uint   // 与 OwnerType 同时指定时作为副本所有者，否则为当前主体（未携带主体时为原文章所有者）
+	OwnerType string
+	TableID   string // 表格文章副本的 tableId，为空时自动生成
+}
+
+// DuplicateArticle 复制文章及其内容（需要对原文章有 viewer 权限、对目标文件夹有 editor 权限），返回新文章
+// Markdown / 富文本复制当前内容，表格复制结构、列顺序、过滤条件与全部行；修订与结构历史从副本创建时重新开始，
+// 标签、评论、附件、分享链接与授权不复制。
+func (s *Service) DuplicateArticle(ctx context.Context, id uint, opts *DuplicateArticleOptions) (*model.Article, error) {
+	source, err := s.getArticleFor(ctx, id, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &DuplicateArticleOptions{}
+	}
+
+	input := &CreateArticleInput{
+		Title:       opts.Title,
+		ArticleType: source.ArticleType,
+		FolderID:    source.FolderID,
+		OwnerID:     source.OwnerID,
+		OwnerType:   source.OwnerType,
+	}
+	if input.Title == "" {
+		input.Title = source.Title + " 副本"
+	}
+	if opts.FolderID != nil {
+		input.FolderID = *opts.FolderID
+	}
+	if opts.OwnerType != "" || opts.OwnerID != 0 {
+		if opts.OwnerType == "" || opts.OwnerID == 0 {
+			return nil, ErrBadRequest.WithMsg("所有者类型与ID需要同时指定")
+		}
+		input.OwnerID, input.OwnerType = opts.OwnerID, opts.OwnerType
+	} else if p, ok := PrincipalFromContext(ctx); ok {
+		// 只读者复制得到的是自己的文章
+		input.OwnerID, input.OwnerType = p.ID, p.Type
+	}
+
+	tableID := opts.TableID
+	if source.ArticleType == model.ArticleTypeTable {
+		if tableID == "" {
+			tableID = newTableID()
+		} else if _, err := s.tableRepo.FindByTableID(ctx, tableID); err == nil {
+			return nil, ErrBadRequest.WithMsgf("tableId 已存在: %s", tableID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+	}
+
+	var article *model.Article
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		var err error
+		article, err = s.createArticle(ctx, repos, input)
+		if err != nil {
+			return err
+		}
+
+		switch source.ArticleType {
+		case model.ArticleTypeMarkdown:
+			return s.duplicateMarkdown(ctx, repos, source.ID, article.ID)
+		case model.ArticleTypeRichText:
+			return s.duplicateRichText(ctx, repos, source.ID, article.ID)
+		case model.ArticleTypeTable:
+			return s.duplicateTable(ctx, repos, source.ID, article.ID, tableID)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "复制文章失败", zap.Uint("article_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.InfoCtx(ctx, "文章复制成功", zap.Uint("source_id", id), zap.Uint("article_id", article.ID))
+	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
+	s.indexArticle(ctx, article.ID)
+	return article, nil
+}
+
+// duplicateMarkdown 复制 Markdown 内容（含 HTML 缓存）；原文章没有内容记录时跳过
+func (s *Service) duplicateMarkdown(ctx context.Context, repos *Repositories, sourceID, articleID uint) error {
+	source, err := repos.Markdown.FindByArticleID(ctx, sourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	now := time.Now()
+	markdown := &model.MarkdownArticle{
+		ArticleID:     articleID,
+		Content:       source.Content,
+		HTMLContent:   source.HTMLContent,
+		HTMLHash:      source.HTMLHash,
+		FormatVersion: source.FormatVersion,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := repos.Markdown.Create(ctx, markdown); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return s.recordRevision(ctx, repos, articleID, model.ArticleTypeMarkdown, markdown.Content, model.RevisionChangeCreate, nil)
+}
+
+// duplicateRichText 复制富文本内容；原文章没有内容记录时跳过
+func (s *Service) duplicateRichText(ctx context.Context, repos *Repositories, sourceID, articleID uint) error {
+	source, err := repos.RichText.FindByArticleID(ctx, sourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	now := time.Now()
+	richText := &model.RichTextArticle{
+		ArticleID:     articleID,
+		Content:       source.Content,
+		FormatVersion: source.FormatVersion,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := repos.RichText.Create(ctx, richText); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return s.recordRevision(ctx, repos, articleID, model.ArticleTypeRichText, richText.Content, model.RevisionChangeCreate, nil)
+}
+
+// duplicateTable 复制表格结构与全部行（按行顺序分批复制，行位置重新编号）；原文章没有表格结构时跳过
+func (s *Service) duplicateTable(ctx context.Context, repos *Repositories, sourceID, articleID uint, tableID string) error {
+	source, err := repos.Table.FindByArticleID(ctx, sourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	now := time.Now()
+	table := &model.TableArticle{
+		ArticleID:   articleID,
+		TableID:     tableID,
+		Structure:   source.Structure,
+		ColumnOrder: source.ColumnOrder,
+		Filters:     source.Filters,
+		Version:     1,
+		DataVersion: 1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repos.Table.Create(ctx, table); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	if err := s.recordStructureHistory(ctx, repos, table, model.StructureChangeCreate, fmt.Sprintf("复制自文章 %d", sourceID)); err != nil {
+		return err
+	}
+
+	index := 0
+	for offset := 0; ; offset += exportBatchSize {
+		rows, err := repos.TableRow.FindBatch(ctx, sourceID, offset, exportBatchSize)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		if len(rows) > 0 {
+			copies := make([]model.TableArticleRow, len(rows))
+			for i, row := range rows {
+				idx := index
+				index++
+				copies[i] = model.TableArticleRow{
+					ArticleID: articleID,
+					RowData:   row.RowData,
+					RowIndex:  &idx,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				if copies[i].RowData == nil {
+					copies[i].RowData = model.JSONMap{}
+				}
+			}
+			if err := repos.TableRow.BatchCreate(ctx, copies); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+	}
+}
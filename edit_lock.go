@@ -0,0 +1,277 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 编辑锁 ====================
+//
+// 启用方式：WithEditLocks(repo)。编辑锁是独占的：有效锁存在时，只有持有者可以修改文章内容，
+// 其他主体的写操作返回 ErrLocked；没有有效锁时不限制。持有者需在过期前通过 RenewEditLock 心跳续期。
+
+// 编辑锁有效期
+const (
+	DefaultEditLockTTL = 2 * time.Minute
+	MaxEditLockTTL     = 30 * time.Minute
+)
+
+// WithEditLocks 注入编辑锁仓储，启用文章独占编辑锁
+func WithEditLocks(r ArticleEditLockRepository) ServiceOption {
+	return func(s *Service) {
+		s.editLockRepo = r
+	}
+}
+
+// AcquireEditLock 获取文章编辑锁（需要 editor），已持有时等同于续期
+// ttl <= 0 时使用 DefaultEditLockTTL，超过 MaxEditLockTTL 时截断。
+func (s *Service) AcquireEditLock(ctx context.Context, articleID uint, ttl time.Duration) (*model.ArticleEditLock, error) {
+	p, err := s.lockPrincipal(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		lock = &model.ArticleEditLock{
+			ArticleID:  articleID,
+			HolderID:   p.ID,
+			HolderType: p.Type,
+			Version:    1,
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(editLockTTL(ttl)),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := s.editLockRepo.Create(ctx, lock); err != nil {
+			// 唯一索引冲突：其他主体同时获取了锁
+			if current, ferr := s.findEditLock(ctx, articleID); ferr == nil && current != nil {
+				return nil, lockedError(current)
+			}
+			s.logger.ErrorCtx(ctx, "获取编辑锁失败", zap.Uint("article_id", articleID), zap.Error(err))
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+		return lock, nil
+	}
+
+	if !lock.HeldBy(p.ID, p.Type) && !lock.IsExpired(now) {
+		return nil, lockedError(lock)
+	}
+	return s.takeEditLock(ctx, lock, p, ttl)
+}
+
+// RenewEditLock 心跳续期（需要仍持有锁）
+// 锁已过期但尚未被他人获取时同样可以续期；已被他人获取或接管时返回 ErrLocked。
+func (s *Service) RenewEditLock(ctx context.Context, articleID uint, ttl time.Duration) (*model.ArticleEditLock, error) {
+	p, err := s.lockPrincipal(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil || !lock.HeldBy(p.ID, p.Type) {
+		if lock != nil && !lock.IsExpired(time.Now()) {
+			return nil, lockedError(lock)
+		}
+		return nil, ErrBadRequest.WithMsg("未持有编辑锁，请重新获取")
+	}
+	return s.takeEditLock(ctx, lock, p, ttl)
+}
+
+// ReleaseEditLock 释放编辑锁（仅持有者）；没有锁或锁已过期时直接返回
+func (s *Service) ReleaseEditLock(ctx context.Context, articleID uint) error {
+	p, err := s.lockPrincipal(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil || lock == nil {
+		return err
+	}
+	if !lock.HeldBy(p.ID, p.Type) {
+		if lock.IsExpired(time.Now()) {
+			return nil
+		}
+		return lockedError(lock)
+	}
+
+	if err := s.editLockRepo.Delete(ctx, lock.ID, lock.Version); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			// 释放前已被接管
+			return ErrLocked.WithMsg("编辑锁已被他人接管")
+		}
+		s.logger.ErrorCtx(ctx, "释放编辑锁失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+// StealEditLock 强制接管编辑锁（需要 owner），原持有者的后续写操作与心跳将返回 ErrLocked
+func (s *Service) StealEditLock(ctx context.Context, articleID uint, ttl time.Duration) (*model.ArticleEditLock, error) {
+	p, err := s.lockPrincipal(ctx, articleID, model.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil || lock.HeldBy(p.ID, p.Type) {
+		return s.AcquireEditLock(ctx, articleID, ttl)
+	}
+
+	previous := *lock
+	lock, err = s.takeEditLock(ctx, lock, p, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !previous.IsExpired(time.Now()) {
+		s.dispatchAsync(ctx, NewArticleEditLockStolenEvent(&previous, lock))
+	}
+	s.logger.InfoCtx(ctx, "编辑锁被接管",
+		zap.Uint("article_id", articleID),
+		zap.Uint("previous_holder", previous.HolderID),
+		zap.Uint("holder", p.ID))
+	return lock, nil
+}
+
+// GetEditLock 获取文章当前有效的编辑锁（需要 viewer），无锁或已过期时返回 nil
+func (s *Service) GetEditLock(ctx context.Context, articleID uint) (*model.ArticleEditLock, error) {
+	if err := s.requireEditLocks(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil || lock == nil || lock.IsExpired(time.Now()) {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// checkEditLock 校验当前主体可以修改文章内容（未启用编辑锁时不校验）
+// 只用于在开始写入前尽早失败，写入事务内由 inEditTx 再次校验。
+func (s *Service) checkEditLock(ctx context.Context, articleID uint) error {
+	if s.editLockRepo == nil {
+		return nil
+	}
+	lock, err := s.findEditLock(ctx, articleID)
+	if err != nil {
+		return err
+	}
+	return editLockError(ctx, lock)
+}
+
+// inEditTx 在事务内先以共享锁重新读取编辑锁再执行 fn
+// 锁在事务提交前不会被接管，持有者的锁在 checkEditLock 之后被接管时写入不会生效。
+func (s *Service) inEditTx(ctx context.Context, articleID uint, fn func(ctx context.Context, repos *Repositories) error) error {
+	return s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		if repos.EditLock != nil {
+			lock, err := repos.EditLock.FindByArticleIDForShare(ctx, articleID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDatabaseError.Wrap(err)
+			}
+			if err := editLockError(ctx, lock); err != nil {
+				return err
+			}
+		}
+		return fn(ctx, repos)
+	})
+}
+
+// editLockError 锁有效且不由当前主体持有时返回 ErrLocked
+func editLockError(ctx context.Context, lock *model.ArticleEditLock) error {
+	if lock == nil || lock.IsExpired(time.Now()) {
+		return nil
+	}
+	if p, ok := PrincipalFromContext(ctx); ok && lock.HeldBy(p.ID, p.Type) {
+		return nil
+	}
+	return lockedError(lock)
+}
+
+// takeEditLock 由 p 持有锁并把过期时间延长 ttl；换了持有者时重置获得时间
+func (s *Service) takeEditLock(ctx context.Context, lock *model.ArticleEditLock, p *Principal, ttl time.Duration) (*model.ArticleEditLock, error) {
+	now := time.Now()
+	updated := *lock
+	if !updated.HeldBy(p.ID, p.Type) {
+		updated.HolderID = p.ID
+		updated.HolderType = p.Type
+		updated.AcquiredAt = now
+	}
+	updated.Version++
+	updated.ExpiresAt = now.Add(editLockTTL(ttl))
+	updated.UpdatedAt = now
+
+	if err := s.editLockRepo.Update(ctx, &updated, lock.Version); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			// 读取之后锁已被他人获取、续期或接管
+			return nil, ErrLocked.WithMsg("编辑锁状态已变化，请重试")
+		}
+		s.logger.ErrorCtx(ctx, "更新编辑锁失败", zap.Uint("article_id", lock.ArticleID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return &updated, nil
+}
+
+// lockPrincipal 校验编辑锁已启用、文章权限，并返回当前主体
+func (s *Service) lockPrincipal(ctx context.Context, articleID uint, minRole string) (*Principal, error) {
+	if err := s.requireEditLocks(); err != nil {
+		return nil, err
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden.WithMsg("未登录")
+	}
+	if _, err := s.getArticleFor(ctx, articleID, minRole); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// findEditLock 读取文章的编辑锁记录（含已过期），不存在时返回 nil
+func (s *Service) findEditLock(ctx context.Context, articleID uint) (*model.ArticleEditLock, error) {
+	lock, err := s.editLockRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return lock, nil
+}
+
+func (s *Service) requireEditLocks() error {
+	if s.editLockRepo == nil {
+		return ErrBadRequest.WithMsg("未启用编辑锁")
+	}
+	return nil
+}
+
+// lockedError 带上持有者与到期时间，便于客户端提示
+func lockedError(lock *model.ArticleEditLock) error {
+	return ErrLocked.WithMsgf("文章正被他人编辑（%s %d，%s 到期）", lock.HolderType, lock.HolderID, lock.ExpiresAt.Format(time.RFC3339))
+}
+
+func editLockTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return DefaultEditLockTTL
+	}
+	return min(ttl, MaxEditLockTTL)
+}
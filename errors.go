@@ -46,4 +46,31 @@ var (
 		"文章已删除",
 		http.StatusNotFound,
 	))
+
+	// ErrForbidden 无权访问
+	ErrForbidden = errcode.Register(errcode.New(
+		ModuleArticle, 1005,
+		"article",
+		"error.article.forbidden",
+		"无权访问该文章",
+		http.StatusForbidden,
+	))
+
+	// ErrConflict 版本冲突（内容已被他人修改）
+	ErrConflict = errcode.Register(errcode.New(
+		ModuleArticle, 1006,
+		"article",
+		"error.article.conflict",
+		"内容已被他人修改，请刷新后重试",
+		http.StatusConflict,
+	))
+
+	// ErrLocked 文章正被他人独占编辑
+	ErrLocked = errcode.Register(errcode.New(
+		ModuleArticle, 1007,
+		"article",
+		"error.article.locked",
+		"文章正被他人编辑",
+		http.StatusLocked,
+	))
 )
@@ -1,6 +1,7 @@
 package article
 
 import (
+	"github.com/KOMKZ/go-yogan-domain-article/model"
 	"github.com/KOMKZ/go-yogan-framework/cache"
 	"github.com/KOMKZ/go-yogan-framework/event"
 )
@@ -17,6 +18,10 @@ const (
 	EventArticleDeleted        = "article:deleted"
 	EventArticleMoved          = "article:moved"
 	EventArticleContentUpdated = "article:content:updated"
+	EventArticlePublished      = "article:published"
+	EventArticleUnpublished    = "article:unpublished"
+	EventArticleRestored       = "article:restored"
+	EventArticlePurged         = "article:purged"
 )
 
 // ArticleCreatedEvent 文章创建事件
@@ -33,6 +38,20 @@ type ArticleDeletedEvent struct {
 	FolderID  *uint // 删除前所属的文件夹
 }
 
+// ArticleRestoredEvent 文章从回收站恢复事件
+type ArticleRestoredEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	FolderID  *uint
+}
+
+// ArticlePurgedEvent 文章被彻底删除事件
+type ArticlePurgedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	FolderID  *uint
+}
+
 // ArticleMovedEvent 文章移动事件
 type ArticleMovedEvent struct {
 	event.BaseEvent
@@ -59,6 +78,24 @@ func NewArticleDeletedEvent(articleID uint, folderID *uint) *ArticleDeletedEvent
 	}
 }
 
+// NewArticleRestoredEvent 创建文章恢复事件
+func NewArticleRestoredEvent(articleID uint, folderID *uint) *ArticleRestoredEvent {
+	return &ArticleRestoredEvent{
+		BaseEvent: event.NewEvent(EventArticleRestored),
+		ArticleID: articleID,
+		FolderID:  folderID,
+	}
+}
+
+// NewArticlePurgedEvent 创建文章彻底删除事件
+func NewArticlePurgedEvent(articleID uint, folderID *uint) *ArticlePurgedEvent {
+	return &ArticlePurgedEvent{
+		BaseEvent: event.NewEvent(EventArticlePurged),
+		ArticleID: articleID,
+		FolderID:  folderID,
+	}
+}
+
 // NewArticleMovedEvent 创建文章移动事件
 func NewArticleMovedEvent(articleID uint, oldFolderID, newFolderID *uint) *ArticleMovedEvent {
 	return &ArticleMovedEvent{
@@ -69,6 +106,30 @@ func NewArticleMovedEvent(articleID uint, oldFolderID, newFolderID *uint) *Artic
 	}
 }
 
+// ArticlePublicationEvent 文章发布/下线事件
+type ArticlePublicationEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	FolderID  *uint
+	Published bool
+	Scheduled bool // 是否由定时计划触发
+}
+
+// NewArticlePublicationEvent 创建文章发布事件（published=false 时为下线）
+func NewArticlePublicationEvent(articleID uint, folderID *uint, published, scheduled bool) *ArticlePublicationEvent {
+	name := EventArticlePublished
+	if !published {
+		name = EventArticleUnpublished
+	}
+	return &ArticlePublicationEvent{
+		BaseEvent: event.NewEvent(name),
+		ArticleID: articleID,
+		FolderID:  folderID,
+		Published: published,
+		Scheduled: scheduled,
+	}
+}
+
 // ArticleContentUpdatedEvent 文章内容更新事件
 type ArticleContentUpdatedEvent struct {
 	event.BaseEvent
@@ -96,3 +157,143 @@ func (e *ArticleDeletedEvent) CacheArgs() []any {
 func (e *ArticleContentUpdatedEvent) CacheArgs() []any {
 	return []any{e.ArticleID}
 }
+
+// ============== 评论事件 ==============
+
+// 评论事件名称常量
+const (
+	EventArticleCommentCreated  = "article:comment:created"
+	EventArticleCommentUpdated  = "article:comment:updated"
+	EventArticleCommentDeleted  = "article:comment:deleted"
+	EventArticleCommentResolved = "article:comment:resolved"
+	EventArticleCommentReopened = "article:comment:reopened"
+)
+
+// ArticleCommentCreatedEvent 评论创建事件（含回复）
+type ArticleCommentCreatedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	CommentID uint
+	ParentID  *uint // 被回复的评论，顶层评论为 nil
+	AuthorID  uint
+}
+
+// NewArticleCommentCreatedEvent 创建评论创建事件
+func NewArticleCommentCreatedEvent(articleID, commentID uint, parentID *uint, authorID uint) *ArticleCommentCreatedEvent {
+	return &ArticleCommentCreatedEvent{
+		BaseEvent: event.NewEvent(EventArticleCommentCreated),
+		ArticleID: articleID,
+		CommentID: commentID,
+		ParentID:  parentID,
+		AuthorID:  authorID,
+	}
+}
+
+// ArticleCommentUpdatedEvent 评论编辑事件
+type ArticleCommentUpdatedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	CommentID uint
+}
+
+// NewArticleCommentUpdatedEvent 创建评论编辑事件
+func NewArticleCommentUpdatedEvent(articleID, commentID uint) *ArticleCommentUpdatedEvent {
+	return &ArticleCommentUpdatedEvent{
+		BaseEvent: event.NewEvent(EventArticleCommentUpdated),
+		ArticleID: articleID,
+		CommentID: commentID,
+	}
+}
+
+// ArticleCommentDeletedEvent 评论删除事件
+type ArticleCommentDeletedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	CommentID uint
+}
+
+// NewArticleCommentDeletedEvent 创建评论删除事件
+func NewArticleCommentDeletedEvent(articleID, commentID uint) *ArticleCommentDeletedEvent {
+	return &ArticleCommentDeletedEvent{
+		BaseEvent: event.NewEvent(EventArticleCommentDeleted),
+		ArticleID: articleID,
+		CommentID: commentID,
+	}
+}
+
+// ArticleCommentResolvedEvent 讨论串解决/重新打开事件
+type ArticleCommentResolvedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	CommentID uint // 讨论串顶层评论
+	Resolved  bool
+}
+
+// NewArticleCommentResolvedEvent 创建讨论串状态变更事件（resolved=false 时为重新打开）
+func NewArticleCommentResolvedEvent(articleID, commentID uint, resolved bool) *ArticleCommentResolvedEvent {
+	name := EventArticleCommentResolved
+	if !resolved {
+		name = EventArticleCommentReopened
+	}
+	return &ArticleCommentResolvedEvent{
+		BaseEvent: event.NewEvent(name),
+		ArticleID: articleID,
+		CommentID: commentID,
+		Resolved:  resolved,
+	}
+}
+
+// ============== 协同编辑事件 ==============
+
+// EventMarkdownOperationApplied Markdown 协同编辑操作已应用
+const EventMarkdownOperationApplied = "article:markdown:operation"
+
+// MarkdownOperationAppliedEvent 协同编辑操作应用事件
+// 异步分发不保证到达顺序，接收方按 Version 排序，发现缺口时通过 ListMarkdownOperations 补齐。
+type MarkdownOperationAppliedEvent struct {
+	event.BaseEvent
+	ArticleID uint
+	Version   int                 // 应用后的内容版本
+	Operation model.TextOperation // 作用于 Version-1 的操作
+	ClientID  string              // 提交方客户端标识，提交方据此确认自己的操作
+	AuthorID  uint
+}
+
+// NewMarkdownOperationAppliedEvent 创建协同编辑操作应用事件
+func NewMarkdownOperationAppliedEvent(op *model.MarkdownOperation) *MarkdownOperationAppliedEvent {
+	return &MarkdownOperationAppliedEvent{
+		BaseEvent: event.NewEvent(EventMarkdownOperationApplied),
+		ArticleID: op.ArticleID,
+		Version:   op.Version,
+		Operation: op.Operation,
+		ClientID:  op.ClientID,
+		AuthorID:  op.AuthorID,
+	}
+}
+
+// ============== 编辑锁事件 ==============
+
+// EventArticleEditLockStolen 编辑锁被所有者强制接管
+const EventArticleEditLockStolen = "article:lock:stolen"
+
+// ArticleEditLockStolenEvent 编辑锁接管事件，用于通知原持有者停止编辑
+type ArticleEditLockStolenEvent struct {
+	event.BaseEvent
+	ArticleID        uint
+	PreviousHolderID uint
+	PreviousType     string
+	HolderID         uint
+	HolderType       string
+}
+
+// NewArticleEditLockStolenEvent 创建编辑锁接管事件
+func NewArticleEditLockStolenEvent(previous, current *model.ArticleEditLock) *ArticleEditLockStolenEvent {
+	return &ArticleEditLockStolenEvent{
+		BaseEvent:        event.NewEvent(EventArticleEditLockStolen),
+		ArticleID:        current.ArticleID,
+		PreviousHolderID: previous.HolderID,
+		PreviousType:     previous.HolderType,
+		HolderID:         current.HolderID,
+		HolderType:       current.HolderType,
+	}
+}
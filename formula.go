@@ -0,0 +1,769 @@
+package article
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 公式列表达式语言
+//
+//	字面量   123  1.5  "文本"  'text'  true  false  null
+//	列引用   amount  {列 名}（field 含空格或特殊字符时用花括号）
+//	运算符   + - * / %  &（文本拼接）  = != <> < <= > >=  && || !
+//	函数     IF AND OR NOT ISBLANK COALESCE CONCAT LEN UPPER LOWER TRIM
+//	         ROUND ABS MIN MAX SUM AVG DATEDIFF TODAY
+//
+// 空单元格在算术中视为 0，在拼接中视为空串；运算出错（如除以 0、结果为 NaN 或溢出）时单元格结果为空。
+
+// 公式限制
+const (
+	maxFormulaLength = 2000
+	maxFormulaDepth  = 64
+)
+
+var (
+	errFormulaDivideByZero = errors.New("除数为 0")
+	errFormulaNotFinite    = errors.New("计算结果超出数值范围")
+)
+
+// formulaExpr 已解析的公式
+type formulaExpr struct {
+	root formulaNode
+	refs []string // 引用的列，按出现顺序去重
+}
+
+// parseFormula 解析公式表达式
+func parseFormula(src string) (*formulaExpr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errors.New("公式为空")
+	}
+	if len(src) > maxFormulaLength {
+		return nil, fmt.Errorf("公式长度不能超过 %d", maxFormulaLength)
+	}
+	tokens, err := lexFormula(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens, refSeen: make(map[string]bool)}
+	root, err := p.parseExpr(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("位置 %d 处有多余内容: %s", tok.pos, tok.text)
+	}
+	return &formulaExpr{root: root, refs: p.refs}, nil
+}
+
+// eval 按行数据计算公式
+func (e *formulaExpr) eval(row map[string]interface{}) (interface{}, error) {
+	return e.root.eval(row)
+}
+
+// ==================== 词法分析 ====================
+
+type formulaTokenKind int
+
+const (
+	tokEOF formulaTokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokField // {field}
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type formulaToken struct {
+	kind formulaTokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func lexFormula(src string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				j := i + 1
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+					for i = j; i < len(src) && src[i] >= '0' && src[i] <= '9'; i++ {
+					}
+				}
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("位置 %d 处数字无效: %s", start, src[start:i])
+			}
+			tokens = append(tokens, formulaToken{kind: tokNumber, num: n, text: src[start:i], pos: start})
+
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			i += size
+			closed := false
+			for i < len(src) {
+				c, n := utf8.DecodeRuneInString(src[i:])
+				i += n
+				if c == '\\' && i < len(src) {
+					esc, m := utf8.DecodeRuneInString(src[i:])
+					i += m
+					switch esc {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(esc)
+					}
+					continue
+				}
+				if c == r {
+					closed = true
+					break
+				}
+				sb.WriteRune(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("位置 %d 处字符串未结束", start)
+			}
+			tokens = append(tokens, formulaToken{kind: tokString, text: sb.String(), pos: start})
+
+		case r == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("位置 %d 处列引用未结束", start)
+			}
+			field := strings.TrimSpace(src[i+1 : i+end])
+			if field == "" {
+				return nil, fmt.Errorf("位置 %d 处列引用为空", start)
+			}
+			i += end + 1
+			tokens = append(tokens, formulaToken{kind: tokField, text: field, pos: start})
+
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				c, n := utf8.DecodeRuneInString(src[i:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+				i += n
+			}
+			tokens = append(tokens, formulaToken{kind: tokIdent, text: src[start:i], pos: start})
+
+		case r == '(':
+			i++
+			tokens = append(tokens, formulaToken{kind: tokLParen, text: "(", pos: start})
+		case r == ')':
+			i++
+			tokens = append(tokens, formulaToken{kind: tokRParen, text: ")", pos: start})
+		case r == ',':
+			i++
+			tokens = append(tokens, formulaToken{kind: tokComma, text: ",", pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "<=", ">=", "!=", "<>", "==", "+", "-", "*", "/", "%", "&", "=", "<", ">", "!"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("位置 %d 处有无法识别的字符: %q", start, r)
+			}
+			i += len(op)
+			tokens = append(tokens, formulaToken{kind: tokOp, text: op, pos: start})
+		}
+	}
+	return append(tokens, formulaToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+// ==================== 语法分析 ====================
+
+// 二元运算符优先级（数值越大越先结合）
+var formulaPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"=":  3,
+	"==": 3,
+	"!=": 3,
+	"<>": 3,
+	"<":  4,
+	"<=": 4,
+	">":  4,
+	">=": 4,
+	"&":  5,
+	"+":  6,
+	"-":  6,
+	"*":  7,
+	"/":  7,
+	"%":  7,
+}
+
+type formulaParser struct {
+	tokens  []formulaToken
+	pos     int
+	refs    []string
+	refSeen map[string]bool
+}
+
+func (p *formulaParser) peek() formulaToken {
+	return p.tokens[p.pos]
+}
+
+func (p *formulaParser) next() formulaToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseExpr 按优先级爬升解析二元表达式
+func (p *formulaParser) parseExpr(minPrec, depth int) (formulaNode, error) {
+	if depth > maxFormulaDepth {
+		return nil, errors.New("公式嵌套过深")
+	}
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := formulaPrecedence[tok.text]
+		if tok.kind != tokOp || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(prec, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *formulaParser) parseUnary(depth int) (formulaNode, error) {
+	if depth > maxFormulaDepth {
+		return nil, errors.New("公式嵌套过深")
+	}
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "-" || tok.text == "+" || tok.text == "!") {
+		p.next()
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: tok.text, operand: operand}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *formulaParser) parsePrimary(depth int) (formulaNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{value: tok.num}, nil
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+	case tokField:
+		return p.fieldRef(tok.text), nil
+
+	case tokLParen:
+		expr, err := p.parseExpr(0, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("位置 %d 处缺少右括号", tok.pos)
+		}
+		return expr, nil
+
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if p.peek().kind != tokLParen {
+			return p.fieldRef(tok.text), nil
+		}
+		p.next()
+		return p.parseCall(tok, depth)
+
+	case tokEOF:
+		return nil, errors.New("公式意外结束")
+	}
+	return nil, fmt.Errorf("位置 %d 处语法错误: %s", tok.pos, tok.text)
+}
+
+func (p *formulaParser) parseCall(name formulaToken, depth int) (formulaNode, error) {
+	fn, ok := formulaFuncs[strings.ToUpper(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的函数: %s", name.text)
+	}
+
+	var args []formulaNode
+	if p.peek().kind == tokRParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpr(0, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				break
+			}
+			if sep.kind != tokComma {
+				return nil, fmt.Errorf("位置 %d 处函数参数之间缺少逗号", sep.pos)
+			}
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("函数 %s 的参数个数不正确", strings.ToUpper(name.text))
+	}
+	return &callNode{name: strings.ToUpper(name.text), fn: fn, args: args}, nil
+}
+
+func (p *formulaParser) fieldRef(field string) formulaNode {
+	if !p.refSeen[field] {
+		p.refSeen[field] = true
+		p.refs = append(p.refs, field)
+	}
+	return &fieldNode{field: field}
+}
+
+// ==================== 求值 ====================
+
+type formulaNode interface {
+	eval(row map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type fieldNode struct{ field string }
+
+func (n *fieldNode) eval(row map[string]interface{}) (interface{}, error) {
+	return formulaValue(row[n.field]), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand formulaNode
+}
+
+func (n *unaryNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !formulaTruthy(v), nil
+	}
+	x, err := formulaNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "-" {
+		return -x, nil
+	}
+	return x, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right formulaNode
+}
+
+func (n *binaryNode) eval(row map[string]interface{}) (interface{}, error) {
+	l, err := n.left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	// 逻辑运算短路
+	switch n.op {
+	case "&&":
+		if !formulaTruthy(l) {
+			return false, nil
+		}
+		r, err := n.right.eval(row)
+		return err == nil && formulaTruthy(r), err
+	case "||":
+		if formulaTruthy(l) {
+			return true, nil
+		}
+		r, err := n.right.eval(row)
+		return err == nil && formulaTruthy(r), err
+	}
+
+	r, err := n.right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&":
+		return formulaText(l) + formulaText(r), nil
+	case "=", "==":
+		return formulaEquals(l, r), nil
+	case "!=", "<>":
+		return !formulaEquals(l, r), nil
+	case "<", "<=", ">", ">=":
+		c, ok := compareCells(l, r)
+		if !ok {
+			return nil, fmt.Errorf("无法比较 %v 与 %v", l, r)
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+
+	x, err := formulaNumber(l)
+	if err != nil {
+		return nil, err
+	}
+	y, err := formulaNumber(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return formulaFinite(x + y)
+	case "-":
+		return formulaFinite(x - y)
+	case "*":
+		return formulaFinite(x * y)
+	case "/":
+		if y == 0 {
+			return nil, errFormulaDivideByZero
+		}
+		return formulaFinite(x / y)
+	case "%":
+		if y == 0 {
+			return nil, errFormulaDivideByZero
+		}
+		return formulaFinite(math.Mod(x, y))
+	}
+	return nil, fmt.Errorf("不支持的运算符: %s", n.op)
+}
+
+type callNode struct {
+	name string
+	fn   formulaFunc
+	args []formulaNode
+}
+
+func (n *callNode) eval(row map[string]interface{}) (interface{}, error) {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(row, n.args)
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return n.fn.call(args)
+}
+
+// ==================== 函数 ====================
+
+type formulaFunc struct {
+	minArgs, maxArgs int // maxArgs < 0 表示不限
+	call             func(args []interface{}) (interface{}, error)
+	lazy             func(row map[string]interface{}, args []formulaNode) (interface{}, error) // 需要按条件求值参数的函数
+}
+
+var formulaFuncs = map[string]formulaFunc{
+	"IF": {minArgs: 2, maxArgs: 3, lazy: func(row map[string]interface{}, args []formulaNode) (interface{}, error) {
+		cond, err := args[0].eval(row)
+		if err != nil {
+			return nil, err
+		}
+		if formulaTruthy(cond) {
+			return args[1].eval(row)
+		}
+		if len(args) == 3 {
+			return args[2].eval(row)
+		}
+		return nil, nil
+	}},
+	"AND": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if !formulaTruthy(a) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}},
+	"OR": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if formulaTruthy(a) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}},
+	"NOT": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return !formulaTruthy(args[0]), nil
+	}},
+	"ISBLANK": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return isEmptyCell(args[0]), nil
+	}},
+	"COALESCE": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if !isEmptyCell(a) {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}},
+	"CONCAT": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, a := range args {
+			sb.WriteString(formulaText(a))
+		}
+		return sb.String(), nil
+	}},
+	"LEN": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return float64(utf8.RuneCountInString(formulaText(args[0]))), nil
+	}},
+	"UPPER": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(formulaText(args[0])), nil
+	}},
+	"LOWER": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(formulaText(args[0])), nil
+	}},
+	"TRIM": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.TrimSpace(formulaText(args[0])), nil
+	}},
+	"ROUND": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		x, err := formulaNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		digits := 0.0
+		if len(args) == 2 {
+			if digits, err = formulaNumber(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		scale := math.Pow(10, math.Trunc(digits))
+		return formulaFinite(math.Round(x*scale) / scale)
+	}},
+	"ABS": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		x, err := formulaNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return math.Abs(x), nil
+	}},
+	"MIN": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return formulaFold(args, math.Min)
+	}},
+	"MAX": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return formulaFold(args, math.Max)
+	}},
+	"SUM": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return formulaFold(args, func(a, b float64) float64 { return a + b })
+	}},
+	"AVG": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		sum, err := formulaFold(args, func(a, b float64) float64 { return a + b })
+		if err != nil || sum == nil {
+			return nil, err
+		}
+		count := 0
+		for _, a := range args {
+			if !isEmptyCell(a) {
+				count++
+			}
+		}
+		return sum.(float64) / float64(count), nil
+	}},
+	"DATEDIFF": {minArgs: 2, maxArgs: 3, call: formulaDateDiff},
+	"TODAY": {minArgs: 0, maxArgs: 0, call: func(args []interface{}) (interface{}, error) {
+		return time.Now().Format(time.DateOnly), nil
+	}},
+}
+
+// formulaFold 对非空参数依次累积，全部为空时返回 nil
+func formulaFold(args []interface{}, fn func(a, b float64) float64) (interface{}, error) {
+	var acc *float64
+	for _, a := range args {
+		if isEmptyCell(a) {
+			continue
+		}
+		x, err := formulaNumber(a)
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			acc = &x
+			continue
+		}
+		v := fn(*acc, x)
+		acc = &v
+	}
+	if acc == nil {
+		return nil, nil
+	}
+	return formulaFinite(*acc)
+}
+
+// formulaDateDiff DATEDIFF(开始, 结束[, 单位])：结束减开始，单位 day（默认）/ hour / minute
+func formulaDateDiff(args []interface{}) (interface{}, error) {
+	if isEmptyCell(args[0]) || isEmptyCell(args[1]) {
+		return nil, nil
+	}
+	start, err := formulaTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := formulaTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	d := end.Sub(start)
+
+	unit := "day"
+	if len(args) == 3 {
+		unit = strings.ToLower(formulaText(args[2]))
+	}
+	switch unit {
+	case "day", "days", "d":
+		return math.Trunc(d.Hours() / 24), nil
+	case "hour", "hours", "h":
+		return math.Trunc(d.Hours()), nil
+	case "minute", "minutes", "m":
+		return math.Trunc(d.Minutes()), nil
+	}
+	return nil, fmt.Errorf("DATEDIFF 不支持的单位: %s", unit)
+}
+
+// ==================== 值转换 ====================
+
+// formulaValue 将单元格值统一为 float64 / string / bool / nil
+func formulaValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, string, bool:
+		return val
+	}
+	if n, ok := numericValue(v); ok {
+		return n
+	}
+	return formatCellText(v)
+}
+
+func formulaNumber(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if strings.TrimSpace(val) == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("%q 不是数字", val)
+		}
+		return n, nil
+	}
+	if n, ok := numericValue(v); ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("%v 不是数字", v)
+}
+
+// formulaFinite NaN / ±Inf 无法序列化为 JSON，也会污染聚合结果，视为计算错误（单元格为空）
+func formulaFinite(x float64) (interface{}, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, errFormulaNotFinite
+	}
+	return x, nil
+}
+
+func formulaText(v interface{}) string {
+	return formatCellText(v)
+}
+
+func formulaTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case float64:
+		return val != 0
+	}
+	return true
+}
+
+func formulaEquals(a, b interface{}) bool {
+	if isEmptyCell(a) || isEmptyCell(b) {
+		return isEmptyCell(a) && isEmptyCell(b)
+	}
+	c, ok := compareCells(a, b)
+	return ok && c == 0
+}
+
+func formulaTime(v interface{}) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%v 不是日期", v)
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q 不是日期", s)
+}
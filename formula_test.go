@@ -0,0 +1,285 @@
+package article
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormulaEval(t *testing.T) {
+	row := map[string]interface{}{
+		"price":  12.5,
+		"qty":    4,
+		"name":   "  widget ",
+		"tag":    "A",
+		"empty":  nil,
+		"active": true,
+		"单价":     "3",
+		"start":  "2024-01-01",
+		"end":    "2024-01-31",
+	}
+
+	tests := []struct {
+		formula string
+		want    interface{}
+	}{
+		// 优先级与结合性
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"24 / 4 / 2", 3.0},
+		{"-2 * 3", -6.0},
+		{"- -2", 2.0},
+		{"7 % 4 * 2", 6.0},
+		{"1 + 2 & 3", "33"},
+		{"1 + 1 = 2", true},
+		{"1 < 2 = true", true},
+		{"1 = 1 && 2 > 3 || true", true},
+		{"false || 1 = 1 && 2 > 3", false},
+		{"!false && true", true},
+
+		// 列引用与空值
+		{"price * qty", 50.0},
+		{"{单价} * 2", 6.0},
+		{"empty + 1", 1.0},
+		{"empty & \"x\"", "x"},
+		{"ISBLANK(empty)", true},
+		{"missing = null", true},
+
+		// 函数
+		{"IF(active, \"yes\", \"no\")", "yes"},
+		{"IF(empty, 1)", nil},
+		{"IF(true, 1, 1 / 0)", 1.0},
+		{"COALESCE(empty, tag)", "A"},
+		{"CONCAT(tag, \"-\", qty)", "A-4"},
+		{"LEN(\"你好\")", 2.0},
+		{"UPPER(TRIM(name))", "WIDGET"},
+		{"ROUND(2.345, 2)", 2.35},
+		{"ROUND(2.5)", 3.0},
+		{"ABS(-3)", 3.0},
+		{"MIN(3, empty, 1, 2)", 1.0},
+		{"MAX(3, 1, 2)", 3.0},
+		{"SUM(price, qty, empty)", 16.5},
+		{"AVG(2, empty, 4)", 3.0},
+		{"SUM(empty)", nil},
+		{"DATEDIFF(start, end)", 30.0},
+		{"DATEDIFF(start, empty)", nil},
+		{"and(true, 1)", true},
+		{"OR(false, 0)", false},
+		{"NOT(0)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			expr, err := parseFormula(tt.formula)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := expr.eval(row)
+			if err != nil {
+				t.Fatalf("eval: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormulaEvalErrors(t *testing.T) {
+	row := map[string]interface{}{
+		"big":  1e308,
+		"text": "abc",
+		"nan":  "NaN",
+	}
+
+	tests := []struct {
+		formula string
+		wantErr error // 为 nil 时只要求出错
+	}{
+		{"1 / 0", errFormulaDivideByZero},
+		{"5 % 0", errFormulaDivideByZero},
+		{"big * 10", errFormulaNotFinite},
+		{"big + big", errFormulaNotFinite},
+		{"ROUND(1.5, 400)", errFormulaNotFinite},
+		{"SUM(big, big)", errFormulaNotFinite},
+		{"AVG(big, big)", errFormulaNotFinite},
+		{"text + 1", nil},
+		{"nan * 1", nil},
+		{"\"a\" < 1", nil},
+		{"DATEDIFF(\"2024-01-01\", \"2024-01-02\", \"week\")", nil},
+		{"IF(true, 1 / 0, 1)", errFormulaDivideByZero},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			expr, err := parseFormula(tt.formula)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := expr.eval(row)
+			if err == nil {
+				t.Fatalf("want error, got %#v", got)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"1 +",
+		"(1 + 2",
+		"1 2",
+		"UNKNOWN(1)",
+		"ROUND()",
+		"NOT(1, 2)",
+		"TODAY(1)",
+		"IF(1 2)",
+		"\"unterminated",
+		"{unterminated",
+		strings.Repeat("(", maxFormulaDepth+2) + "1" + strings.Repeat(")", maxFormulaDepth+2),
+		strings.Repeat("-", maxFormulaDepth+2) + "1",
+		strings.Repeat("1+", maxFormulaLength) + "1",
+	}
+
+	for _, src := range tests {
+		name := src
+		if len(name) > 40 {
+			name = name[:40] + "..."
+		}
+		t.Run(name, func(t *testing.T) {
+			if expr, err := parseFormula(src); err == nil {
+				t.Fatalf("want error, got %+v", expr)
+			}
+		})
+	}
+}
+
+func TestParseFormulaRefs(t *testing.T) {
+	expr, err := parseFormula("a + b * a + {c d} + IF(b, e)")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := []string{"a", "b", "c d", "e"}; !reflect.DeepEqual(expr.refs, want) {
+		t.Fatalf("refs = %v, want %v", expr.refs, want)
+	}
+}
+
+func TestTableSchemaFormulas(t *testing.T) {
+	col := func(field, typ, formula string) map[string]interface{} {
+		raw := map[string]interface{}{"field": field, "type": typ}
+		if formula != "" {
+			raw["formula"] = formula
+		}
+		return raw
+	}
+
+	tests := []struct {
+		name    string
+		columns []map[string]interface{}
+		row     map[string]interface{}
+		want    map[string]interface{} // 期望的公式列结果
+		wantErr string
+	}{
+		{
+			name: "按依赖顺序计算",
+			columns: []map[string]interface{}{
+				col("total", "formula", "subtotal + tax"),
+				col("tax", "formula", "subtotal * 0.1"),
+				col("subtotal", "formula", "price * qty"),
+				col("price", "number", ""),
+				col("qty", "integer", ""),
+			},
+			row:  map[string]interface{}{"price": 10, "qty": 3},
+			want: map[string]interface{}{"subtotal": 30.0, "tax": 3.0, "total": 33.0},
+		},
+		{
+			name: "计算出错的单元格为空",
+			columns: []map[string]interface{}{
+				col("a", "number", ""),
+				col("ratio", "formula", "1 / a"),
+				col("huge", "formula", "a * 1e308"),
+				col("next", "formula", "COALESCE(ratio, -1)"),
+			},
+			row:  map[string]interface{}{"a": 0, "ratio": 99},
+			want: map[string]interface{}{"ratio": nil, "huge": 0.0, "next": -1.0},
+		},
+		{
+			name: "结果溢出时为空",
+			columns: []map[string]interface{}{
+				col("a", "number", ""),
+				col("huge", "formula", "a * 1e308"),
+				col("rounded", "formula", "ROUND(a, 400)"),
+			},
+			row:  map[string]interface{}{"a": 10},
+			want: map[string]interface{}{"huge": nil, "rounded": nil},
+		},
+		{
+			name: "自引用",
+			columns: []map[string]interface{}{
+				col("a", "formula", "a + 1"),
+			},
+			wantErr: "循环引用",
+		},
+		{
+			name: "间接循环引用",
+			columns: []map[string]interface{}{
+				col("a", "formula", "b + 1"),
+				col("b", "formula", "c + 1"),
+				col("c", "formula", "a + 1"),
+			},
+			wantErr: "循环引用: a -> b -> c -> a",
+		},
+		{
+			name: "引用不存在的列",
+			columns: []map[string]interface{}{
+				col("a", "formula", "missing + 1"),
+			},
+			wantErr: "不存在的列",
+		},
+		{
+			name: "公式语法错误",
+			columns: []map[string]interface{}{
+				col("a", "formula", "1 +"),
+			},
+			wantErr: "公式无效",
+		},
+		{
+			name: "公式列不支持写入约束",
+			columns: []map[string]interface{}{
+				{"field": "a", "type": "formula", "formula": "1", "required": true},
+			},
+			wantErr: "不支持 required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseTableSchema(tt.columns)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTableSchema: %v", err)
+			}
+			if !schema.HasFormulas() {
+				t.Fatal("HasFormulas = false")
+			}
+			schema.ApplyFormulas(tt.row)
+			for field, want := range tt.want {
+				if got := tt.row[field]; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %#v, want %#v", field, got, want)
+				}
+			}
+		})
+	}
+}
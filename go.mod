@@ -4,7 +4,9 @@ go 1.24.0
 
 require (
 	github.com/KOMKZ/go-yogan-framework v0.0.0
+	github.com/yuin/goldmark v1.7.13
 	go.uber.org/zap v1.27.1
+	golang.org/x/net v0.48.0
 	gorm.io/gorm v1.31.1
 )
 
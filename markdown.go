@@ -0,0 +1,110 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MarkdownRenderer Markdown 渲染器
+type MarkdownRenderer interface {
+	// Name 渲染器标识（建议包含版本/配置），变化后已缓存的 HTML 会被视为过期
+	Name() string
+	Render(source string) (string, error)
+}
+
+// GoldmarkRenderer 基于 goldmark 的 CommonMark + GFM 渲染器
+// 支持表格、任务列表、删除线、自动链接与围栏代码块；原始 HTML 会被转义。
+type GoldmarkRenderer struct {
+	md goldmark.Markdown
+}
+
+func NewGoldmarkRenderer() *GoldmarkRenderer {
+	return &GoldmarkRenderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		),
+	}
+}
+
+func (r *GoldmarkRenderer) Name() string {
+	return "goldmark-gfm/1"
+}
+
+func (r *GoldmarkRenderer) Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MarkdownArticleHTML Markdown文章渲染结果
+type MarkdownArticleHTML struct {
+	Article *model.Article `json:"article"`
+	HTML    string         `json:"html"`
+}
+
+// GetMarkdownArticleHTML 获取Markdown文章渲染后的HTML
+// 优先返回缓存；缓存缺失或内容/渲染器变化时重新渲染并回写缓存。
+func (s *Service) GetMarkdownArticleHTML(ctx context.Context, articleID uint) (*MarkdownArticleHTML, error) {
+	article, err := s.GetArticle(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if article.ArticleType != model.ArticleTypeMarkdown {
+		return nil, ErrBadRequest.WithMsg("该文章不是Markdown类型")
+	}
+
+	markdown, err := s.markdownRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &MarkdownArticleHTML{Article: article, HTML: ""}, nil
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	if markdown.HTMLHash != s.markdownHash(markdown.Content) {
+		s.renderMarkdown(ctx, markdown)
+		// 只回写缓存字段且不改变版本；回写失败不影响本次读取，下次访问会再次渲染
+		if err := s.markdownRepo.UpdateHTMLCache(ctx, markdown); err != nil {
+			s.logger.ErrorCtx(ctx, "回写Markdown HTML缓存失败", zap.Uint("article_id", articleID), zap.Error(err))
+		}
+	}
+
+	return &MarkdownArticleHTML{
+		Article: article,
+		HTML:    markdown.HTMLContent,
+	}, nil
+}
+
+// renderMarkdown 渲染 Markdown 并填充 HTML 缓存字段
+// 渲染失败时清空缓存指纹，保存不受影响，读取时会重试渲染。
+func (s *Service) renderMarkdown(ctx context.Context, markdown *model.MarkdownArticle) {
+	html, err := s.markdownRenderer.Render(markdown.Content)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "渲染Markdown失败", zap.Uint("article_id", markdown.ArticleID), zap.Error(err))
+		markdown.HTMLContent = ""
+		markdown.HTMLHash = ""
+		return
+	}
+	markdown.HTMLContent = html
+	markdown.HTMLHash = s.markdownHash(markdown.Content)
+}
+
+// markdownHash 计算内容与当前渲染器的指纹
+func (s *Service) markdownHash(content string) string {
+	sum := sha256.Sum256([]byte(s.markdownRenderer.Name() + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
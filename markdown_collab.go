@@ -0,0 +1,246 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== Markdown 协同编辑 ====================
+//
+// 客户端基于自己看到的内容版本提交文本操作，服务端将其依次变换到期间已应用的操作之上，
+// 应用后内容版本加 1 并写入操作日志，再通过 MarkdownOperationAppliedEvent 广播给其他客户端。
+// 整篇保存（UpdateMarkdownContent、恢复修订）同样写入一条操作，保证日志连续。
+
+// maxCollabClientIDLength 客户端标识最大长度
+const maxCollabClientIDLength = 64
+
+// collabMaxAttempts 并发提交导致版本冲突时的最大尝试次数
+const collabMaxAttempts = 3
+
+// CollabOptions 协同编辑参数
+type CollabOptions struct {
+	SnapshotInterval int // 每应用多少个操作记录一次修订快照（需启用修订历史），<= 0 表示不记录
+	HistoryLimit     int // 客户端最多可落后的版本数（须大于 0），超过需重新加载文档；更早的操作日志在快照时清理
+}
+
+// DefaultCollabOptions 默认每 100 个操作一次快照，保留最近 1000 个操作
+func DefaultCollabOptions() CollabOptions {
+	return CollabOptions{
+		SnapshotInterval: 100,
+		HistoryLimit:     1000,
+	}
+}
+
+// WithMarkdownCollaboration 注入操作日志仓储，启用 Markdown 协同编辑
+func WithMarkdownCollaboration(r MarkdownOperationRepository) ServiceOption {
+	return func(s *Service) {
+		s.markdownOpRepo = r
+	}
+}
+
+// WithCollabOptions 设置协同编辑参数
+func WithCollabOptions(opts CollabOptions) ServiceOption {
+	return func(s *Service) {
+		s.collabOptions = opts
+	}
+}
+
+// SubmitMarkdownOperationInput 提交协同编辑操作输入
+type SubmitMarkdownOperationInput struct {
+	BaseVersion int                 // 操作所基于的内容版本
+	ClientID    string              // 客户端标识，随事件原样广播
+	Operation   model.TextOperation // 覆盖 BaseVersion 时整篇文档的操作
+}
+
+// SubmitMarkdownOperation 提交协同编辑操作（需要 editor），返回实际应用的操作记录
+// 返回的 Operation 已变换到上一版本之上；客户端落后过多或所需的操作日志已清理时返回 ErrConflict，需重新加载文档。
+func (s *Service) SubmitMarkdownOperation(ctx context.Context, articleID uint, input *SubmitMarkdownOperationInput) (*model.MarkdownOperation, error) {
+	if err := s.requireCollab(); err != nil {
+		return nil, err
+	}
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if article.ArticleType != model.ArticleTypeMarkdown {
+		return nil, ErrBadRequest.WithMsg("该文章不是Markdown类型")
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return nil, err
+	}
+	if len(input.ClientID) > maxCollabClientIDLength {
+		return nil, ErrBadRequest.WithMsgf("客户端标识不能超过 %d 个字符", maxCollabClientIDLength)
+	}
+	operation, err := normalizeTextOperation(input.Operation)
+	if err != nil {
+		return nil, ErrBadRequest.WithMsgf("操作无效: %v", err)
+	}
+
+	var applied *model.MarkdownOperation
+	var snapshot bool
+	for attempt := 1; ; attempt++ {
+		err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+			var err error
+			applied, snapshot, err = s.applyMarkdownOperation(ctx, repos, articleID, input.BaseVersion, input.ClientID, operation)
+			return err
+		})
+		// 与其他提交同时写入时重新读取最新内容再变换一次
+		if !errors.Is(err, ErrStaleVersion) || attempt == collabMaxAttempts {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return nil, ErrConflict.WithMsg("提交过于频繁，请稍后重试")
+		}
+		return nil, err
+	}
+
+	s.dispatchAsync(ctx, NewMarkdownOperationAppliedEvent(applied))
+	s.dispatchAsync(ctx, NewArticleContentUpdatedEvent(articleID, "markdown"))
+	// 逐个操作重建索引代价过高，随快照一起更新
+	if snapshot {
+		s.indexArticle(ctx, articleID)
+	}
+	return applied, nil
+}
+
+// applyMarkdownOperation 变换并应用操作，返回写入的操作记录及是否记录了快照
+// 内容被并发修改时原样返回 ErrStaleVersion，由调用方重试。
+func (s *Service) applyMarkdownOperation(ctx context.Context, repos *Repositories, articleID uint, baseVersion int, clientID string, operation model.TextOperation) (*model.MarkdownOperation, bool, error) {
+	markdown, err := repos.Markdown.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrNotFound.WithMsg("Markdown内容不存在")
+		}
+		return nil, false, ErrDatabaseError.Wrap(err)
+	}
+	if baseVersion < 1 || baseVersion > markdown.Version {
+		return nil, false, ErrBadRequest.WithMsgf("基础版本无效: %d", baseVersion)
+	}
+
+	if lag := markdown.Version - baseVersion; lag > 0 {
+		if lag > s.collabOptions.HistoryLimit {
+			return nil, false, ErrConflict.WithMsg("落后版本过多，请重新加载文档")
+		}
+		concurrent, err := repos.MarkdownOp.FindSince(ctx, articleID, baseVersion, lag)
+		if err != nil {
+			return nil, false, ErrDatabaseError.Wrap(err)
+		}
+		if len(concurrent) != lag || concurrent[0].Version != baseVersion+1 {
+			return nil, false, ErrConflict.WithMsg("操作历史已清理，请重新加载文档")
+		}
+		for _, c := range concurrent {
+			if operation, _, err = transformTextOperations(operation, c.Operation); err != nil {
+				return nil, false, ErrBadRequest.WithMsgf("操作与基础版本不匹配: %v", err)
+			}
+		}
+	}
+
+	content, err := applyTextOperation(markdown.Content, operation)
+	if err != nil {
+		return nil, false, ErrBadRequest.WithMsgf("操作与当前内容不匹配: %v", err)
+	}
+
+	// 不在这里渲染 HTML：缓存指纹与新内容不一致，读取 HTML 时会按需重新渲染
+	markdown.Content = content
+	markdown.Version++
+	markdown.UpdatedAt = time.Now()
+	if err := repos.Markdown.Update(ctx, markdown, markdown.Version-1); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return nil, false, err
+		}
+		return nil, false, ErrDatabaseError.Wrap(err)
+	}
+
+	applied := &model.MarkdownOperation{
+		ArticleID: articleID,
+		Version:   markdown.Version,
+		Operation: operation,
+		ClientID:  clientID,
+		CreatedAt: time.Now(),
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		applied.AuthorID = p.ID
+		applied.AuthorType = p.Type
+	}
+	if err := repos.MarkdownOp.Create(ctx, applied); err != nil {
+		s.logger.ErrorCtx(ctx, "写入协同编辑操作失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, false, ErrDatabaseError.Wrap(err)
+	}
+
+	interval := s.collabOptions.SnapshotInterval
+	if interval <= 0 || markdown.Version%interval != 0 {
+		return applied, false, nil
+	}
+	if err := s.recordRevision(ctx, repos, articleID, model.ArticleTypeMarkdown, content, model.RevisionChangeCollab, nil); err != nil {
+		return nil, false, err
+	}
+	if keepFrom := markdown.Version - s.collabOptions.HistoryLimit; keepFrom > 0 {
+		if err := repos.MarkdownOp.DeleteBefore(ctx, articleID, keepFrom); err != nil {
+			return nil, false, ErrDatabaseError.Wrap(err)
+		}
+	}
+	return applied, true, nil
+}
+
+// ListMarkdownOperations 按版本升序返回 sinceVersion 之后已应用的操作（需要 viewer），用于客户端补齐缺失的广播
+// 所需操作已被清理时返回 ErrConflict，客户端需重新加载文档。
+func (s *Service) ListMarkdownOperations(ctx context.Context, articleID uint, sinceVersion, limit int) ([]model.MarkdownOperation, error) {
+	if err := s.requireCollab(); err != nil {
+		return nil, err
+	}
+	article, err := s.getArticleFor(ctx, articleID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if article.ArticleType != model.ArticleTypeMarkdown {
+		return nil, ErrBadRequest.WithMsg("该文章不是Markdown类型")
+	}
+	if limit <= 0 || limit > s.collabOptions.HistoryLimit {
+		limit = s.collabOptions.HistoryLimit
+	}
+
+	ops, err := s.markdownOpRepo.FindSince(ctx, articleID, sinceVersion, limit)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if len(ops) > 0 && ops[0].Version != sinceVersion+1 {
+		return nil, ErrConflict.WithMsg("操作历史已清理，请重新加载文档")
+	}
+	return ops, nil
+}
+
+// recordMarkdownOperation 将整篇保存记为一条操作，使协同编辑中的客户端可以在其之上继续变换（未启用协同编辑时跳过）
+func (s *Service) recordMarkdownOperation(ctx context.Context, repos *Repositories, markdown *model.MarkdownArticle, previous string) error {
+	if repos.MarkdownOp == nil {
+		return nil
+	}
+	op := &model.MarkdownOperation{
+		ArticleID: markdown.ArticleID,
+		Version:   markdown.Version,
+		Operation: diffTextOperation(previous, markdown.Content),
+		CreatedAt: time.Now(),
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		op.AuthorID = p.ID
+		op.AuthorType = p.Type
+	}
+	if err := repos.MarkdownOp.Create(ctx, op); err != nil {
+		s.logger.ErrorCtx(ctx, "写入协同编辑操作失败", zap.Uint("article_id", markdown.ArticleID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) requireCollab() error {
+	if s.markdownOpRepo == nil {
+		return ErrBadRequest.WithMsg("未启用协同编辑")
+	}
+	return nil
+}
@@ -4,15 +4,19 @@ import "time"
 
 // Article 文章总表实体
 type Article struct {
-	ID          uint      `gorm:"primarykey" json:"id"`
-	Title       string    `gorm:"size:255;not null" json:"title"`
-	ArticleType string    `gorm:"size:50;not null;index" json:"article_type"` // table, markdown, rich_text
-	FolderID    *uint     `gorm:"index" json:"folder_id"`                     // 文件夹ID（可空）
-	OwnerID     uint      `gorm:"not null" json:"owner_id"`
-	OwnerType   string    `gorm:"size:50;not null;index" json:"owner_type"` // user, admin, team
-	Status      int       `gorm:"not null;default:1;index" json:"status"`   // 0=草稿, 1=已发布, 2=已删除
-	CreatedAt   time.Time `gorm:"not null;index" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+	ID          uint       `gorm:"primarykey" json:"id"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	ArticleType string     `gorm:"size:50;not null;index" json:"article_type"` // table, markdown, rich_text
+	FolderID    *uint      `gorm:"index" json:"folder_id"`                     // 文件夹ID（可空）
+	OwnerID     uint       `gorm:"not null" json:"owner_id"`
+	OwnerType   string     `gorm:"size:50;not null;index" json:"owner_type"` // user, admin, team
+	Status      int        `gorm:"not null;default:1;index" json:"status"`   // 0=草稿, 1=已发布, 2=已删除
+	PublishAt   *time.Time `gorm:"index" json:"publish_at"`                  // 定时发布：草稿到期后转为已发布
+	UnpublishAt *time.Time `gorm:"index" json:"unpublish_at"`                // 定时下线：已发布到期后转回草稿
+	DeletedAt   *time.Time `gorm:"index" json:"deleted_at"`                  // 移入回收站的时间
+	PrevStatus  *int       `json:"-"`                                        // 移入回收站前的状态，恢复时还原；为空时恢复为草稿
+	CreatedAt   time.Time  `gorm:"not null;index" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -50,3 +54,13 @@ func (a *Article) IsDeleted() bool {
 func (a *Article) IsPublished() bool {
 	return a.Status == StatusPublished
 }
+
+// PublishDue 在指定时间是否应按计划发布
+func (a *Article) PublishDue(now time.Time) bool {
+	return a.Status == StatusDraft && a.PublishAt != nil && !now.Before(*a.PublishAt)
+}
+
+// UnpublishDue 在指定时间是否应按计划下线
+func (a *Article) UnpublishDue(now time.Time) bool {
+	return a.Status == StatusPublished && a.UnpublishAt != nil && !now.Before(*a.UnpublishAt)
+}
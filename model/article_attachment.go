@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// ArticleAttachment 文章附件（图片、文件），内容存放在 Blob 中
+type ArticleAttachment struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	ArticleID   uint      `gorm:"not null;index" json:"articleId"`
+	BlobID      uint      `gorm:"not null;index" json:"blobId"`
+	FileName    string    `gorm:"size:255;not null" json:"fileName"`
+	ContentType string    `gorm:"size:100;not null" json:"contentType"`
+	Size        int64     `gorm:"not null" json:"size"`
+	UploadedBy  uint      `gorm:"not null;default:0" json:"uploadedBy"`
+	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ArticleAttachment) TableName() string {
+	return "article_attachments"
+}
+
+// ArticleBlob 附件内容（按 SHA-256 去重，RefCount 为引用它的附件数）
+type ArticleBlob struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Hash        string    `gorm:"size:64;not null;uniqueIndex" json:"hash"` // SHA-256 十六进制
+	Size        int64     `gorm:"not null" json:"size"`
+	ContentType string    `gorm:"size:100;not null" json:"contentType"`
+	StorageKey  string    `gorm:"size:255;not null" json:"storageKey"`
+	RefCount    int       `gorm:"not null;default:0" json:"refCount"`
+	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ArticleBlob) TableName() string {
+	return "article_blobs"
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// ArticleComment 文章评论（支持楼中楼回复）
+// 顶层评论的 ParentID、RootID 为空；回复的 RootID 指向所在讨论串的顶层评论。
+type ArticleComment struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	ArticleID  uint       `gorm:"not null;index" json:"articleId"`
+	ParentID   *uint      `gorm:"index" json:"parentId"` // 被回复的评论
+	RootID     *uint      `gorm:"index" json:"rootId"`   // 所在讨论串的顶层评论
+	AuthorID   uint       `gorm:"not null" json:"authorId"`
+	AuthorType string     `gorm:"size:50;not null" json:"authorType"`
+	Content    string     `gorm:"type:text;not null" json:"content"`
+	EditCount  int        `gorm:"not null;default:0" json:"editCount"`
+	EditedAt   *time.Time `json:"editedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"` // 仅顶层评论，讨论串已解决
+	ResolvedBy *uint      `json:"resolvedBy"`
+	DeletedAt  *time.Time `gorm:"index" json:"deletedAt"` // 软删除，保留位置以维持讨论串结构
+	CreatedAt  time.Time  `gorm:"not null" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ArticleComment) TableName() string {
+	return "article_comments"
+}
+
+// IsRoot 是否为顶层评论
+func (c *ArticleComment) IsRoot() bool {
+	return c.ParentID == nil
+}
+
+// IsDeleted 是否已删除
+func (c *ArticleComment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// IsResolved 讨论串是否已解决
+func (c *ArticleComment) IsResolved() bool {
+	return c.ResolvedAt != nil
+}
+
+// ArticleCommentEdit 评论编辑历史（保存编辑前的内容）
+type ArticleCommentEdit struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CommentID  uint      `gorm:"not null;index" json:"commentId"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	EditorID   uint      `gorm:"not null;default:0" json:"editorId"`
+	EditorType string    `gorm:"size:50" json:"editorType"`
+	CreatedAt  time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ArticleCommentEdit) TableName() string {
+	return "article_comment_edits"
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// ArticleEditLock 文章独占编辑锁，每篇文章最多一条，过期后视为未加锁
+type ArticleEditLock struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	ArticleID  uint      `gorm:"not null;uniqueIndex" json:"articleId"`
+	HolderID   uint      `gorm:"not null" json:"holderId"`
+	HolderType string    `gorm:"size:50;not null" json:"holderType"`
+	Version    int       `gorm:"not null;default:1" json:"-"` // 每次获取、续期、接管加 1，用于条件更新
+	AcquiredAt time.Time `gorm:"not null" json:"acquiredAt"`  // 当前持有者获得锁的时间，续期不变
+	ExpiresAt  time.Time `gorm:"not null" json:"expiresAt"`
+	CreatedAt  time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ArticleEditLock) TableName() string {
+	return "article_edit_locks"
+}
+
+// IsExpired 在指定时间是否已过期
+func (l *ArticleEditLock) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// HeldBy 是否由指定主体持有（不判断是否过期）
+func (l *ArticleEditLock) HeldBy(holderID uint, holderType string) bool {
+	return l.HolderID == holderID && l.HolderType == holderType
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// ArticleGrant 访问授权（授予用户或团队对文章/文件夹的角色）
+type ArticleGrant struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	ResourceType string    `gorm:"size:20;not null;uniqueIndex:uk_article_grant,priority:1" json:"resourceType"` // article, folder
+	ResourceID   uint      `gorm:"not null;uniqueIndex:uk_article_grant,priority:2" json:"resourceId"`
+	SubjectType  string    `gorm:"size:20;not null;uniqueIndex:uk_article_grant,priority:3;index:idx_article_grant_subject,priority:1" json:"subjectType"` // user, team
+	SubjectID    uint      `gorm:"not null;uniqueIndex:uk_article_grant,priority:4;index:idx_article_grant_subject,priority:2" json:"subjectId"`
+	Role         string    `gorm:"size:20;not null" json:"role"` // viewer, commenter, editor, owner
+	GrantedBy    uint      `gorm:"not null;default:0" json:"grantedBy"`
+	CreatedAt    time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ArticleGrant) TableName() string {
+	return "article_grants"
+}
+
+// ResourceType 授权资源类型常量
+const (
+	ResourceTypeArticle = "article"
+	ResourceTypeFolder  = "folder"
+)
+
+// SubjectType 授权对象类型常量
+const (
+	SubjectTypeUser = "user"
+	SubjectTypeTeam = "team"
+)
+
+// Role 角色常量（权限依次递增）
+const (
+	RoleViewer    = "viewer"
+	RoleCommenter = "commenter"
+	RoleEditor    = "editor"
+	RoleOwner     = "owner"
+)
+
+// RoleRank 角色等级，无效角色返回 0
+func RoleRank(role string) int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleCommenter:
+		return 2
+	case RoleEditor:
+		return 3
+	case RoleOwner:
+		return 4
+	}
+	return 0
+}
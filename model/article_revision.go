@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ArticleRevision 文章内容修订记录（Markdown / 富文本）
+type ArticleRevision struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	ArticleID    uint      `gorm:"not null;uniqueIndex:uk_article_revision_seq,priority:1" json:"articleId"`
+	Sequence     int       `gorm:"not null;uniqueIndex:uk_article_revision_seq,priority:2" json:"sequence"` // 文章内递增序号，从1开始
+	ContentType  string    `gorm:"size:50;not null" json:"contentType"`                                     // markdown, rich_text
+	Content      string    `gorm:"type:longtext;not null" json:"content,omitempty"`
+	ChangeType   string    `gorm:"size:50;not null" json:"changeType"` // create, update, restore, collab
+	RestoredFrom *int      `json:"restoredFrom"`                       // 恢复来源的修订序号（仅 restore）
+	AuthorID     uint      `gorm:"not null;default:0" json:"authorId"`
+	AuthorType   string    `gorm:"size:50" json:"authorType"`
+	CreatedAt    time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ArticleRevision) TableName() string {
+	return "article_revisions"
+}
+
+// RevisionChangeType 修订类型常量
+const (
+	RevisionChangeCreate  = "create"
+	RevisionChangeUpdate  = "update"
+	RevisionChangeRestore = "restore"
+	RevisionChangeCollab  = "collab" // 协同编辑期间的定期快照
+)
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// ArticleShareLink 文章分享链接（无需账号的只读访问）
+type ArticleShareLink struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	ArticleID    uint       `gorm:"not null;index" json:"articleId"`
+	Token        string     `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	PasswordHash string     `gorm:"size:255" json:"-"`                  // 为空表示无需密码
+	ExpiresAt    *time.Time `json:"expiresAt"`                          // nil 表示永不过期
+	MaxViews     int        `gorm:"not null;default:0" json:"maxViews"` // 0 表示不限次数
+	ViewCount    int        `gorm:"not null;default:0" json:"viewCount"`
+	FailedCount  int        `gorm:"not null;default:0" json:"-"` // 连续密码错误次数，验证成功后清零
+	LastFailedAt *time.Time `json:"-"`                           // 最近一次密码尝试时间，用于计算锁定期
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedBy    uint       `gorm:"not null;default:0" json:"createdBy"`
+	CreatedAt    time.Time  `gorm:"not null" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ArticleShareLink) TableName() string {
+	return "article_share_links"
+}
+
+// HasPassword 是否需要访问密码
+func (l *ArticleShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+// IsRevoked 是否已撤销
+func (l *ArticleShareLink) IsRevoked() bool {
+	return l.RevokedAt != nil
+}
+
+// IsExpired 在指定时间是否已过期
+func (l *ArticleShareLink) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// ViewsExhausted 访问次数是否已用完
+func (l *ArticleShareLink) ViewsExhausted() bool {
+	return l.MaxViews > 0 && l.ViewCount >= l.MaxViews
+}
+
+// PasswordLocked 密码连续错误达到 maxFailures 次后，在最近一次尝试后的 lockout 内拒绝再次尝试
+func (l *ArticleShareLink) PasswordLocked(now time.Time, maxFailures int, lockout time.Duration) bool {
+	return l.FailedCount >= maxFailures && l.LastFailedAt != nil && now.Before(l.LastFailedAt.Add(lockout))
+}
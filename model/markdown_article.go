@@ -8,7 +8,9 @@ type MarkdownArticle struct {
 	ArticleID     uint      `gorm:"uniqueIndex;not null" json:"articleId"`
 	Content       string    `gorm:"type:text;not null" json:"content"`         // Markdown内容
 	HTMLContent   string    `gorm:"type:longtext" json:"htmlContent"`          // 渲染后的HTML（缓存）
+	HTMLHash      string    `gorm:"size:64" json:"-"`                          // 生成 HTML 缓存时的内容+渲染器指纹
 	FormatVersion string    `gorm:"size:20;default:'1.0'" json:"formatVersion"`
+	Version       int       `gorm:"not null;default:1" json:"version"` // 乐观锁版本，每次内容变更加 1
 	CreatedAt     time.Time `gorm:"not null" json:"createdAt"`
 	UpdatedAt     time.Time `gorm:"not null" json:"updatedAt"`
 }
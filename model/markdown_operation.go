@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// MarkdownOperation Markdown 协同编辑操作日志
+// 每条记录对应内容的一次版本递增，Version 为应用该操作后的 MarkdownArticle.Version。
+type MarkdownOperation struct {
+	ID         uint          `gorm:"primarykey" json:"id"`
+	ArticleID  uint          `gorm:"not null;uniqueIndex:uk_markdown_operation_version,priority:1" json:"articleId"`
+	Version    int           `gorm:"not null;uniqueIndex:uk_markdown_operation_version,priority:2" json:"version"`
+	Operation  TextOperation `gorm:"type:json;not null" json:"operation"` // 已变换到上一版本之上的操作
+	ClientID   string        `gorm:"size:64" json:"clientId"`             // 提交方客户端标识，整篇保存时为空
+	AuthorID   uint          `gorm:"not null;default:0" json:"authorId"`
+	AuthorType string        `gorm:"size:50" json:"authorType"`
+	CreatedAt  time.Time     `gorm:"not null" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (MarkdownOperation) TableName() string {
+	return "markdown_operations"
+}
+
+// TextOpComponent 文本操作分量，Retain / Insert / Delete 有且仅有一个非零
+// 长度按 Unicode 码点计算。
+type TextOpComponent struct {
+	Retain int    `json:"retain,omitempty"` // 保留的字符数
+	Insert string `json:"insert,omitempty"` // 插入的文本
+	Delete int    `json:"delete,omitempty"` // 删除的字符数
+}
+
+// TextOperation 覆盖整篇文档的文本操作，依次作用于文档的每个字符
+type TextOperation []TextOpComponent
+
+// Scan 实现 sql.Scanner 接口
+func (o *TextOperation) Scan(value interface{}) error {
+	if value == nil {
+		*o = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, o)
+}
+
+// Value 实现 driver.Valuer 接口
+func (o TextOperation) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(o)
+}
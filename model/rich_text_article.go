@@ -8,6 +8,7 @@ type RichTextArticle struct {
 	ArticleID     uint      `gorm:"uniqueIndex;not null" json:"articleId"`
 	Content       string    `gorm:"type:longtext;not null" json:"content"` // HTML内容
 	FormatVersion string    `gorm:"size:20;default:'1.0'" json:"formatVersion"`
+	Version       int       `gorm:"not null;default:1" json:"version"` // 乐观锁版本，每次内容变更加 1
 	CreatedAt     time.Time `gorm:"not null" json:"createdAt"`
 	UpdatedAt     time.Time `gorm:"not null" json:"updatedAt"`
 }
@@ -14,7 +14,8 @@ type TableArticle struct {
 	Structure   JSONArray `gorm:"type:json;not null" json:"structure"`          // 表结构定义
 	ColumnOrder JSONArray `gorm:"type:json" json:"columnOrder"`                 // 列顺序
 	Filters     JSONArray `gorm:"type:json" json:"filters"`                     // 过滤条件
-	Version     int       `gorm:"not null;default:1" json:"version"`
+	Version     int       `gorm:"not null;default:1" json:"version"`            // 结构版本（乐观锁），每次结构变更加 1
+	DataVersion int       `gorm:"not null;default:1" json:"dataVersion"`        // 行数据版本（乐观锁），每次行数据变更加 1
 	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
 	UpdatedAt   time.Time `gorm:"not null" json:"updatedAt"`
 }
@@ -55,6 +56,29 @@ func (TableArticleStructureHistory) TableName() string {
 	return "table_article_structure_history"
 }
 
+// StructureChangeType 表格结构变更类型常量
+const (
+	StructureChangeCreate   = "create"
+	StructureChangeUpdate   = "update"
+	StructureChangeRollback = "rollback"
+	StructureChangeBaseline = "baseline" // 启用历史前已存在的结构快照
+)
+
+// ColumnType 表格列类型常量（Structure 中列定义的 type 字段）
+const (
+	ColumnTypeText         = "text"
+	ColumnTypeNumber       = "number"
+	ColumnTypeInteger      = "integer"
+	ColumnTypeBoolean      = "boolean"
+	ColumnTypeDate         = "date"     // 2006-01-02
+	ColumnTypeDatetime     = "datetime" // RFC3339
+	ColumnTypeSingleSelect = "single_select"
+	ColumnTypeMultiSelect  = "multi_select"
+	ColumnTypeURL          = "url"
+	ColumnTypeEmail        = "email"
+	ColumnTypeFormula      = "formula" // 值由 formula 表达式按行计算，不存储
+)
+
 // JSONArray JSON数组类型
 type JSONArray []map[string]interface{}
 
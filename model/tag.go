@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Tag 标签（按所有者隔离，同一所有者下名称唯一）
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	OwnerID   uint      `gorm:"not null;uniqueIndex:uk_tag_owner_name,priority:2" json:"ownerId"`
+	OwnerType string    `gorm:"size:50;not null;uniqueIndex:uk_tag_owner_name,priority:1" json:"ownerType"` // user, admin, team
+	Name      string    `gorm:"size:50;not null;uniqueIndex:uk_tag_owner_name,priority:3" json:"name"`
+	Color     string    `gorm:"size:20" json:"color"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// ArticleTag 文章与标签的关联
+type ArticleTag struct {
+	ArticleID uint      `gorm:"primaryKey;autoIncrement:false" json:"articleId"`
+	TagID     uint      `gorm:"primaryKey;autoIncrement:false;index" json:"tagId"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ArticleTag) TableName() string {
+	return "article_tags"
+}
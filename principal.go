@@ -0,0 +1,54 @@
+package article
+
+import (
+	"context"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+)
+
+// Principal 当前操作主体（由应用层在请求入口注入 context）
+type Principal struct {
+	ID      uint
+	Type    string // user, admin
+	TeamIDs []uint // 所属团队，用于团队授权与团队所有的文章
+}
+
+// IsAdmin 管理员不受文章授权限制
+func (p *Principal) IsAdmin() bool {
+	return p.Type == model.OwnerTypeAdmin
+}
+
+// InTeam 是否属于指定团队
+func (p *Principal) InTeam(teamID uint) bool {
+	for _, id := range p.TeamIDs {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal 将操作主体写入 context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// PrincipalFromContext 从 context 读取操作主体
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+type systemAccessCtxKey struct{}
+
+// withSystemAccess 标记由领域内部发起、已在别处完成授权的读取（如分享链接），跳过访问控制
+func withSystemAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemAccessCtxKey{}, true)
+}
+
+func hasSystemAccess(ctx context.Context) bool {
+	ok, _ := ctx.Value(systemAccessCtxKey{}).(bool)
+	return ok
+}
@@ -0,0 +1,131 @@
+package article
+
+import (
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+)
+
+// 分页限制
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// 排序字段常量
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByTitle     = "title"
+	SortByID        = "id"
+	SortByDeletedAt = "deleted_at" // 回收站列表默认排序
+)
+
+// 排序方向常量
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ArticleQuery 文章列表查询条件
+// 集合类条件为空表示不限制；同一字段内为 OR，不同字段之间为 AND。
+type ArticleQuery struct {
+	OwnerIDs     []uint
+	OwnerTypes   []string
+	ArticleTypes []string
+	Statuses     []int // 为空时排除已删除文章
+	FolderIDs    []uint
+	Title        string // 标题模糊匹配
+	TagIDs       []uint // 标签筛选，默认包含任一标签即可
+	MatchAllTags bool   // 为 true 时要求包含全部 TagIDs
+
+	// 时间范围为左闭右开区间 [From, To)
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+
+	SortBy    string // created_at（默认）, updated_at, title, id, deleted_at
+	SortOrder string // desc（默认）, asc
+
+	Page     int // 从 1 开始，默认 1
+	PageSize int // 默认 DefaultPageSize，最大 MaxPageSize
+
+	// VisibleTo 只返回该主体可见的文章；启用访问控制时由服务层按当前主体填充
+	VisibleTo *Principal
+}
+
+// Normalize 校验查询条件并填充默认值
+func (q *ArticleQuery) Normalize() error {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Page < 0 {
+		return ErrBadRequest.WithMsg("页码必须大于0")
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 0 || q.PageSize > MaxPageSize {
+		return ErrBadRequest.WithMsgf("每页数量必须在 1 到 %d 之间", MaxPageSize)
+	}
+
+	q.SortBy = strings.ToLower(q.SortBy)
+	switch q.SortBy {
+	case "":
+		q.SortBy = SortByCreatedAt
+	case SortByCreatedAt, SortByUpdatedAt, SortByTitle, SortByID, SortByDeletedAt:
+	default:
+		return ErrBadRequest.WithMsgf("不支持的排序字段: %s", q.SortBy)
+	}
+
+	q.SortOrder = strings.ToLower(q.SortOrder)
+	switch q.SortOrder {
+	case "":
+		q.SortOrder = SortDesc
+	case SortAsc, SortDesc:
+	default:
+		return ErrBadRequest.WithMsgf("不支持的排序方向: %s", q.SortOrder)
+	}
+
+	for _, t := range q.ArticleTypes {
+		if !isValidArticleType(t) {
+			return ErrBadRequest.WithMsgf("不支持的文章类型: %s", t)
+		}
+	}
+	for _, st := range q.Statuses {
+		if st != model.StatusDraft && st != model.StatusPublished && st != model.StatusDeleted {
+			return ErrBadRequest.WithMsgf("不支持的文章状态: %d", st)
+		}
+	}
+	q.TagIDs = uniqueIDs(q.TagIDs)
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return ErrBadRequest.WithMsg("创建时间范围无效")
+	}
+	if q.UpdatedFrom != nil && q.UpdatedTo != nil && !q.UpdatedFrom.Before(*q.UpdatedTo) {
+		return ErrBadRequest.WithMsg("更新时间范围无效")
+	}
+	return nil
+}
+
+// uniqueIDs 去重并保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	if len(ids) == 0 {
+		return ids
+	}
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Offset 分页偏移量
+func (q *ArticleQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
@@ -2,6 +2,8 @@ package article
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/KOMKZ/go-yogan-domain-article/model"
 )
@@ -9,20 +11,41 @@ import (
 // ArticleRepository 文章仓储接口
 type ArticleRepository interface {
 	Create(ctx context.Context, article *model.Article) error
-	Update(ctx context.Context, article *model.Article) error
+	// Update 只写入 columns 指定的列与 updated_at；仅当库中状态仍为 fromStatus 时生效，否则返回 ErrStaleVersion
+	Update(ctx context.Context, article *model.Article, fromStatus int, columns ...string) error
 	FindByID(ctx context.Context, id uint) (*model.Article, error)
-	Delete(ctx context.Context, id uint) error
-	Paginate(ctx context.Context, page, pageSize int, ownerId *uint, ownerType, articleType, title string, folderID *uint) ([]model.Article, int64, error)
-	// PaginateByFolderIDs 分页查询（支持多个文件夹ID，用于树形筛选）
-	PaginateByFolderIDs(ctx context.Context, page, pageSize int, ownerId *uint, ownerType, articleType, title string, folderIDs []uint) ([]model.Article, int64, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Article, error)
+	// Delete 移入回收站：写入 Status、PrevStatus、DeletedAt；文章已在回收站时返回 ErrStaleVersion
+	Delete(ctx context.Context, article *model.Article) error
+	// Restore 从回收站恢复：写入 Status 并清空 PrevStatus、DeletedAt；文章已不在回收站时返回 ErrStaleVersion
+	Restore(ctx context.Context, article *model.Article) error
+	// HardDelete 物理删除回收站中的文章主表记录；文章已不在回收站（如已被恢复）时返回 ErrStaleVersion
+	HardDelete(ctx context.Context, id uint) error
+	// FindTrashedBefore 按 id 升序返回 id 大于 afterID、早于 before 移入回收站的文章，最多 limit 条
+	FindTrashedBefore(ctx context.Context, before time.Time, afterID uint, limit int) ([]model.Article, error)
+	// Find 按查询条件分页查询（调用方需先调用 ArticleQuery.Normalize）
+	Find(ctx context.Context, q *ArticleQuery) ([]model.Article, int64, error)
+	// ListByCursor 游标分页查询，结果按 created_at DESC, id DESC 排序；cursor 为 nil 表示从最新开始
+	// 使用 q 的过滤条件，忽略其排序与分页字段
+	ListByCursor(ctx context.Context, q *ArticleQuery, cursor *ArticleCursor, limit int) ([]model.Article, error)
 	CountByFolderID(ctx context.Context, folderID uint) (int64, error)
 	FindByFolderID(ctx context.Context, folderID uint) ([]model.Article, error)
+	// FindDueTransitions 按 id 升序返回 id 大于 afterID、到期应定时发布或下线的文章，最多 limit 条
+	FindDueTransitions(ctx context.Context, now time.Time, afterID uint, limit int) ([]model.Article, error)
+	// UpdateSchedule 写入 Status、PublishAt、UnpublishAt；仅当库中状态仍为 fromStatus 时生效，否则返回 ErrStaleVersion
+	UpdateSchedule(ctx context.Context, article *model.Article, fromStatus int) error
 }
 
+// ErrStaleVersion 按版本条件更新时库中版本已变化（由服务层转换为 ErrConflict）
+var ErrStaleVersion = errors.New("article: stale version")
+
 // MarkdownArticleRepository Markdown文章仓储接口
 type MarkdownArticleRepository interface {
 	Create(ctx context.Context, article *model.MarkdownArticle) error
-	Update(ctx context.Context, article *model.MarkdownArticle) error
+	// Update 仅当库中版本等于 expectedVersion 时写入（article.Version 为新版本），否则返回 ErrStaleVersion
+	Update(ctx context.Context, article *model.MarkdownArticle, expectedVersion int) error
+	// UpdateHTMLCache 只回写 HTML 缓存，不改变版本；内容已被修改（版本不一致）时静默跳过
+	UpdateHTMLCache(ctx context.Context, article *model.MarkdownArticle) error
 	FindByArticleID(ctx context.Context, articleID uint) (*model.MarkdownArticle, error)
 	DeleteByArticleID(ctx context.Context, articleID uint) error
 }
@@ -30,7 +53,8 @@ type MarkdownArticleRepository interface {
 // RichTextArticleRepository 富文本文章仓储接口
 type RichTextArticleRepository interface {
 	Create(ctx context.Context, article *model.RichTextArticle) error
-	Update(ctx context.Context, article *model.RichTextArticle) error
+	// Update 仅当库中版本等于 expectedVersion 时写入（article.Version 为新版本），否则返回 ErrStaleVersion
+	Update(ctx context.Context, article *model.RichTextArticle, expectedVersion int) error
 	FindByArticleID(ctx context.Context, articleID uint) (*model.RichTextArticle, error)
 	DeleteByArticleID(ctx context.Context, articleID uint) error
 }
@@ -38,7 +62,12 @@ type RichTextArticleRepository interface {
 // TableArticleRepository 表格文章仓储接口
 type TableArticleRepository interface {
 	Create(ctx context.Context, article *model.TableArticle) error
-	Update(ctx context.Context, article *model.TableArticle) error
+	// Update 仅当库中结构版本等于 expectedVersion 时写入（article.Version 为新版本），否则返回 ErrStaleVersion
+	Update(ctx context.Context, article *model.TableArticle, expectedVersion int) error
+	// UpdateFilters 只更新保存的过滤条件，不改变版本
+	UpdateFilters(ctx context.Context, articleID uint, filters model.JSONArray) error
+	// BumpDataVersion 行数据版本加 1 并返回新版本；expectedVersion > 0 时要求当前版本一致，否则返回 ErrStaleVersion
+	BumpDataVersion(ctx context.Context, articleID uint, expectedVersion int) (int, error)
 	FindByArticleID(ctx context.Context, articleID uint) (*model.TableArticle, error)
 	FindByTableID(ctx context.Context, tableID string) (*model.TableArticle, error)
 	DeleteByArticleID(ctx context.Context, articleID uint) error
@@ -49,6 +78,140 @@ type TableArticleRowRepository interface {
 	Create(ctx context.Context, row *model.TableArticleRow) error
 	BatchCreate(ctx context.Context, rows []model.TableArticleRow) error
 	FindByArticleID(ctx context.Context, articleID uint) ([]model.TableArticleRow, error)
+	// FindBatch 按行顺序分批读取，用于导出等流式处理
+	FindBatch(ctx context.Context, articleID uint, offset, limit int) ([]model.TableArticleRow, error)
 	DeleteByArticleID(ctx context.Context, articleID uint) error
 	ReplaceAll(ctx context.Context, articleID uint, rows []model.TableArticleRow) error
+	FindByID(ctx context.Context, id uint) (*model.TableArticleRow, error)
+	FindByIDs(ctx context.Context, articleID uint, ids []uint) ([]model.TableArticleRow, error)
+	Count(ctx context.Context, articleID uint) (int64, error)
+	Update(ctx context.Context, row *model.TableArticleRow) error
+	DeleteByIDs(ctx context.Context, articleID uint, ids []uint) error
+	// ShiftRowIndexes 将 RowIndex 在 [from, to] 区间内的行整体加 delta，to < 0 表示不设上限
+	ShiftRowIndexes(ctx context.Context, articleID uint, from, to, delta int) error
+}
+
+// ArticleRevisionRepository 内容修订记录仓储接口
+type ArticleRevisionRepository interface {
+	Create(ctx context.Context, revision *model.ArticleRevision) error
+	// PaginateByArticleID 按序号倒序分页查询（不加载 Content）
+	PaginateByArticleID(ctx context.Context, articleID uint, page, pageSize int) ([]model.ArticleRevision, int64, error)
+	FindBySequence(ctx context.Context, articleID uint, sequence int) (*model.ArticleRevision, error)
+	MaxSequence(ctx context.Context, articleID uint) (int, error)
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// MarkdownOperationRepository Markdown 协同编辑操作日志仓储接口
+type MarkdownOperationRepository interface {
+	Create(ctx context.Context, op *model.MarkdownOperation) error
+	// FindSince 按版本升序返回版本号大于 version 的操作，最多 limit 条
+	FindSince(ctx context.Context, articleID uint, version, limit int) ([]model.MarkdownOperation, error)
+	// DeleteBefore 删除版本号不大于 version 的操作
+	DeleteBefore(ctx context.Context, articleID uint, version int) error
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// ArticleEditLockRepository 文章编辑锁仓储接口
+type ArticleEditLockRepository interface {
+	FindByArticleID(ctx context.Context, articleID uint) (*model.ArticleEditLock, error)
+	// FindByArticleIDForShare 在事务内读取并加共享锁，事务提交前他人无法接管、续期或释放该锁
+	FindByArticleIDForShare(ctx context.Context, articleID uint) (*model.ArticleEditLock, error)
+	Create(ctx context.Context, lock *model.ArticleEditLock) error
+	// Update 以 expectedVersion 为条件更新，记录已变化时返回 ErrStaleVersion
+	Update(ctx context.Context, lock *model.ArticleEditLock, expectedVersion int) error
+	// Delete 以 version 为条件删除，记录已变化时返回 ErrStaleVersion
+	Delete(ctx context.Context, id uint, version int) error
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// TableArticleStructureHistoryRepository 表格结构变更历史仓储接口
+type TableArticleStructureHistoryRepository interface {
+	Create(ctx context.Context, history *model.TableArticleStructureHistory) error
+	// FindByArticleID 按版本号倒序返回全部历史
+	FindByArticleID(ctx context.Context, articleID uint) ([]model.TableArticleStructureHistory, error)
+	FindByVersion(ctx context.Context, articleID uint, version int) (*model.TableArticleStructureHistory, error)
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// ArticleGrantRepository 访问授权仓储接口
+type ArticleGrantRepository interface {
+	// Upsert 按 (资源, 对象) 新增或更新角色
+	Upsert(ctx context.Context, grant *model.ArticleGrant) error
+	Delete(ctx context.Context, resourceType string, resourceID uint, subjectType string, subjectID uint) error
+	FindByResource(ctx context.Context, resourceType string, resourceID uint) ([]model.ArticleGrant, error)
+	// FindForPrincipal 查询若干资源上授予给用户本人或其所属团队的授权
+	FindForPrincipal(ctx context.Context, resourceType string, resourceIDs []uint, userID uint, teamIDs []uint) ([]model.ArticleGrant, error)
+	DeleteByResource(ctx context.Context, resourceType string, resourceID uint) error
+}
+
+// ArticleShareLinkRepository 分享链接仓储接口
+type ArticleShareLinkRepository interface {
+	Create(ctx context.Context, link *model.ArticleShareLink) error
+	FindByID(ctx context.Context, id uint) (*model.ArticleShareLink, error)
+	FindByToken(ctx context.Context, token string) (*model.ArticleShareLink, error)
+	FindByArticleID(ctx context.Context, articleID uint) ([]model.ArticleShareLink, error)
+	// IncrementViewCount 在未撤销且未超出次数限制时原子递增访问次数，返回是否成功
+	IncrementViewCount(ctx context.Context, id uint) (bool, error)
+	// ReservePasswordAttempt 未处于锁定期时原子地预记一次密码错误（FailedCount 加 1 并记录时间），返回是否允许本次尝试
+	// 锁定期：FailedCount 不小于 maxFailures 且距 LastFailedAt 不足 lockout。
+	ReservePasswordAttempt(ctx context.Context, id uint, now time.Time, maxFailures int, lockout time.Duration) (bool, error)
+	// ResetPasswordFailures 密码验证成功后清零错误次数
+	ResetPasswordFailures(ctx context.Context, id uint) error
+	Revoke(ctx context.Context, id uint, revokedAt time.Time) error
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// TagRepository 标签仓储接口（含文章-标签关联）
+type TagRepository interface {
+	Create(ctx context.Context, tag *model.Tag) error
+	Update(ctx context.Context, tag *model.Tag) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*model.Tag, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Tag, error)
+	FindByNames(ctx context.Context, ownerType string, ownerID uint, names []string) ([]model.Tag, error)
+	FindByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Tag, error)
+
+	// AddToArticle 关联文章与标签（已存在的关联忽略）
+	AddToArticle(ctx context.Context, articleID uint, tagIDs []uint) error
+	RemoveFromArticle(ctx context.Context, articleID uint, tagIDs []uint) error
+	FindByArticleID(ctx context.Context, articleID uint) ([]model.Tag, error)
+	// MoveArticles 将 fromTagID 的全部关联转移到 toTagID（已同时拥有两者的文章去重）
+	MoveArticles(ctx context.Context, fromTagID, toTagID uint) error
+	DeleteArticleTagsByTagID(ctx context.Context, tagID uint) error
+	DeleteArticleTagsByArticleID(ctx context.Context, articleID uint) error
+	// CountArticles 统计所有者各标签下未删除的文章数（tagID -> 数量）
+	CountArticles(ctx context.Context, ownerType string, ownerID uint) (map[uint]int64, error)
+}
+
+// ArticleCommentRepository 评论仓储接口（含编辑历史）
+type ArticleCommentRepository interface {
+	Create(ctx context.Context, comment *model.ArticleComment) error
+	Update(ctx context.Context, comment *model.ArticleComment) error
+	FindByID(ctx context.Context, id uint) (*model.ArticleComment, error)
+	// PaginateRoots 分页查询文章的顶层评论（按创建时间正序）
+	PaginateRoots(ctx context.Context, articleID uint, page, pageSize int) ([]model.ArticleComment, int64, error)
+	// FindByRootIDs 查询若干讨论串下的全部回复（按创建时间正序）
+	FindByRootIDs(ctx context.Context, rootIDs []uint) ([]model.ArticleComment, error)
+	CreateEdit(ctx context.Context, edit *model.ArticleCommentEdit) error
+	// FindEdits 按时间倒序返回评论的编辑历史
+	FindEdits(ctx context.Context, commentID uint) ([]model.ArticleCommentEdit, error)
+	// DeleteByArticleID 物理删除文章的全部评论与编辑历史
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+}
+
+// ArticleAttachmentRepository 附件仓储接口（含 Blob 引用计数）
+type ArticleAttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.ArticleAttachment) error
+	FindByID(ctx context.Context, id uint) (*model.ArticleAttachment, error)
+	FindByArticleID(ctx context.Context, articleID uint) ([]model.ArticleAttachment, error)
+	Delete(ctx context.Context, id uint) error
+	DeleteByArticleID(ctx context.Context, articleID uint) error
+
+	CreateBlob(ctx context.Context, blob *model.ArticleBlob) error
+	FindBlobByID(ctx context.Context, id uint) (*model.ArticleBlob, error)
+	FindBlobByHash(ctx context.Context, hash string) (*model.ArticleBlob, error)
+	// AddBlobRef 调整引用计数（delta 可为负数）；Blob 记录已被删除时返回 ErrStaleVersion
+	AddBlobRef(ctx context.Context, id uint, delta int) error
+	// DeleteBlobIfUnreferenced 引用计数归零时删除 Blob 记录，返回是否删除
+	DeleteBlobIfUnreferenced(ctx context.Context, id uint) (bool, error)
 }
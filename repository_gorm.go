@@ -2,9 +2,12 @@ package article
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/KOMKZ/go-yogan-domain-article/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ArticleGORMRepository GORM 文章仓储实现
@@ -20,8 +23,12 @@ func (r *ArticleGORMRepository) Create(ctx context.Context, article *model.Artic
 	return r.db.WithContext(ctx).Create(article).Error
 }
 
-func (r *ArticleGORMRepository) Update(ctx context.Context, article *model.Article) error {
-	return r.db.WithContext(ctx).Save(article).Error
+func (r *ArticleGORMRepository) Update(ctx context.Context, article *model.Article, fromStatus int, columns ...string) error {
+	selected := append([]string{"updated_at"}, columns...)
+	return updateVersioned(r.db.WithContext(ctx).Model(article).
+		Where("status = ?", fromStatus).
+		Select(selected).
+		Updates(article))
 }
 
 func (r *ArticleGORMRepository) FindByID(ctx context.Context, id uint) (*model.Article, error) {
@@ -33,78 +40,171 @@ func (r *ArticleGORMRepository) FindByID(ctx context.Context, id uint) (*model.A
 	return &article, nil
 }
 
-func (r *ArticleGORMRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Model(&model.Article{}).Where("id = ?", id).Update("status", model.StatusDeleted).Error
+func (r *ArticleGORMRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Article, error) {
+	var articles []model.Article
+	if len(ids) == 0 {
+		return articles, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&articles).Error
+	return articles, err
+}
+
+func (r *ArticleGORMRepository) Delete(ctx context.Context, article *model.Article) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(&model.Article{}).
+		Where("id = ? AND status <> ?", article.ID, model.StatusDeleted).
+		Updates(map[string]interface{}{
+			"status":      model.StatusDeleted,
+			"prev_status": article.PrevStatus,
+			"deleted_at":  article.DeletedAt,
+			"updated_at":  article.UpdatedAt,
+		}))
+}
+
+func (r *ArticleGORMRepository) Restore(ctx context.Context, article *model.Article) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(&model.Article{}).
+		Where("id = ? AND status = ?", article.ID, model.StatusDeleted).
+		Updates(map[string]interface{}{
+			"status":      article.Status,
+			"prev_status": nil,
+			"deleted_at":  nil,
+			"updated_at":  article.UpdatedAt,
+		}))
+}
+
+func (r *ArticleGORMRepository) HardDelete(ctx context.Context, id uint) error {
+	return updateVersioned(r.db.WithContext(ctx).
+		Where("id = ? AND status = ?", id, model.StatusDeleted).
+		Delete(&model.Article{}))
 }
 
-func (r *ArticleGORMRepository) Paginate(ctx context.Context, page, pageSize int, ownerId *uint, ownerType, articleType, title string, folderID *uint) ([]model.Article, int64, error) {
+func (r *ArticleGORMRepository) FindTrashedBefore(ctx context.Context, before time.Time, afterID uint, limit int) ([]model.Article, error) {
 	var articles []model.Article
-	var total int64
+	// 早于回收站功能删除的文章没有 deleted_at，以最后更新时间近似
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND id > ? AND COALESCE(deleted_at, updated_at) < ?", model.StatusDeleted, afterID, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&articles).Error
+	return articles, err
+}
 
-	query := r.db.WithContext(ctx).Model(&model.Article{}).Where("status != ?", model.StatusDeleted)
+func (r *ArticleGORMRepository) Find(ctx context.Context, q *ArticleQuery) ([]model.Article, int64, error) {
+	var articles []model.Article
+	var total int64
 
-	if ownerId != nil {
-		query = query.Where("owner_id = ?", *ownerId)
-	}
-	if ownerType != "" {
-		query = query.Where("owner_type = ?", ownerType)
-	}
-	if articleType != "" {
-		query = query.Where("article_type = ?", articleType)
-	}
-	if title != "" {
-		query = query.Where("title LIKE ?", "%"+title+"%")
-	}
-	if folderID != nil {
-		query = query.Where("folder_id = ?", *folderID)
-	}
+	query := applyArticleQuery(r.db.WithContext(ctx).Model(&model.Article{}), q)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&articles).Error; err != nil {
+	// 追加 id 作为次级排序，保证相同排序值时分页稳定
+	order := q.SortBy + " " + q.SortOrder + ", id " + q.SortOrder
+	if q.SortBy == SortByID {
+		order = "id " + q.SortOrder
+	}
+	if err := query.Offset(q.Offset()).Limit(q.PageSize).Order(order).Find(&articles).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return articles, total, nil
 }
 
-// PaginateByFolderIDs 分页查询（支持多个文件夹ID，用于树形筛选）
-func (r *ArticleGORMRepository) PaginateByFolderIDs(ctx context.Context, page, pageSize int, ownerId *uint, ownerType, articleType, title string, folderIDs []uint) ([]model.Article, int64, error) {
-	var articles []model.Article
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&model.Article{}).Where("status != ?", model.StatusDeleted)
-
-	if ownerId != nil {
-		query = query.Where("owner_id = ?", *ownerId)
+// applyArticleQuery 将查询条件转换为 WHERE 子句
+func applyArticleQuery(query *gorm.DB, q *ArticleQuery) *gorm.DB {
+	if len(q.Statuses) > 0 {
+		query = query.Where("status IN ?", q.Statuses)
+	} else {
+		query = query.Where("status != ?", model.StatusDeleted)
+	}
+	if len(q.OwnerIDs) > 0 {
+		query = query.Where("owner_id IN ?", q.OwnerIDs)
+	}
+	if len(q.OwnerTypes) > 0 {
+		query = query.Where("owner_type IN ?", q.OwnerTypes)
+	}
+	if len(q.ArticleTypes) > 0 {
+		query = query.Where("article_type IN ?", q.ArticleTypes)
+	}
+	if len(q.FolderIDs) > 0 {
+		query = query.Where("folder_id IN ?", q.FolderIDs)
+	}
+	if q.Title != "" {
+		query = query.Where("title LIKE ?", "%"+q.Title+"%")
+	}
+	if len(q.TagIDs) > 0 {
+		tagged := query.Session(&gorm.Session{NewDB: true}).Model(&model.ArticleTag{}).
+			Select("article_id").
+			Where("tag_id IN ?", q.TagIDs)
+		if q.MatchAllTags {
+			tagged = tagged.Group("article_id").Having("COUNT(DISTINCT tag_id) = ?", len(q.TagIDs))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+	if q.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *q.CreatedFrom)
 	}
-	if ownerType != "" {
-		query = query.Where("owner_type = ?", ownerType)
+	if q.CreatedTo != nil {
+		query = query.Where("created_at < ?", *q.CreatedTo)
 	}
-	if articleType != "" {
-		query = query.Where("article_type = ?", articleType)
+	if q.UpdatedFrom != nil {
+		query = query.Where("updated_at >= ?", *q.UpdatedFrom)
 	}
-	if title != "" {
-		query = query.Where("title LIKE ?", "%"+title+"%")
+	if q.UpdatedTo != nil {
+		query = query.Where("updated_at < ?", *q.UpdatedTo)
 	}
-	// 使用 IN 查询多个文件夹
-	if len(folderIDs) > 0 {
-		query = query.Where("folder_id IN ?", folderIDs)
+	if p := q.VisibleTo; p != nil && !p.IsAdmin() {
+		query = query.Where(visibleCondition(query, p))
 	}
+	return query
+}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// visibleCondition 主体可见的文章：本人/所属团队所有，或在文章、所在文件夹上有授权
+// 注意：列表查询只识别直接所在文件夹的授权，不展开上级文件夹继承。
+func visibleCondition(db *gorm.DB, p *Principal) *gorm.DB {
+	grantedIDs := func(resourceType string) *gorm.DB {
+		return db.Session(&gorm.Session{NewDB: true}).Model(&model.ArticleGrant{}).
+			Select("resource_id").
+			Where("resource_type = ?", resourceType).
+			Where(grantSubjectCondition(db, p.ID, p.TeamIDs))
 	}
 
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&articles).Error; err != nil {
-		return nil, 0, err
+	cond := db.Session(&gorm.Session{NewDB: true}).Where("owner_type = ? AND owner_id = ?", p.Type, p.ID)
+	if len(p.TeamIDs) > 0 {
+		cond = cond.Or("owner_type = ? AND owner_id IN ?", model.OwnerTypeTeam, p.TeamIDs)
 	}
+	return cond.
+		Or("id IN (?)", grantedIDs(model.ResourceTypeArticle)).
+		Or("folder_id IN (?)", grantedIDs(model.ResourceTypeFolder))
+}
 
-	return articles, total, nil
+// ListByCursor 游标分页查询（基于 created_at + id 的 keyset 分页，不使用 OFFSET）
+func (r *ArticleGORMRepository) ListByCursor(ctx context.Context, q *ArticleQuery, cursor *ArticleCursor, limit int) ([]model.Article, error) {
+	var articles []model.Article
+
+	query := applyArticleQuery(r.db.WithContext(ctx).Model(&model.Article{}), q)
+
+	order := "created_at DESC, id DESC"
+	if cursor != nil {
+		if cursor.Backward {
+			// 向前翻页：取比游标更新的记录，升序取最近的 limit 条后再反转
+			query = query.Where("(created_at > ? OR (created_at = ? AND id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+			order = "created_at ASC, id ASC"
+		} else {
+			query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+	}
+
+	if err := query.Order(order).Limit(limit).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(articles)-1; i < j; i, j = i+1, j-1 {
+			articles[i], articles[j] = articles[j], articles[i]
+		}
+	}
+	return articles, nil
 }
 
 func (r *ArticleGORMRepository) CountByFolderID(ctx context.Context, folderID uint) (int64, error) {
@@ -124,6 +224,29 @@ func (r *ArticleGORMRepository) FindByFolderID(ctx context.Context, folderID uin
 	return articles, err
 }
 
+func (r *ArticleGORMRepository) FindDueTransitions(ctx context.Context, now time.Time, afterID uint, limit int) ([]model.Article, error) {
+	var articles []model.Article
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Where("((status = ? AND publish_at <= ?) OR (status = ? AND unpublish_at <= ?))",
+			model.StatusDraft, now, model.StatusPublished, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&articles).Error
+	return articles, err
+}
+
+func (r *ArticleGORMRepository) UpdateSchedule(ctx context.Context, article *model.Article, fromStatus int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(&model.Article{}).
+		Where("id = ? AND status = ?", article.ID, fromStatus).
+		Updates(map[string]interface{}{
+			"status":       article.Status,
+			"publish_at":   article.PublishAt,
+			"unpublish_at": article.UnpublishAt,
+			"updated_at":   article.UpdatedAt,
+		}))
+}
+
 // MarkdownArticleGORMRepository GORM Markdown文章仓储实现
 type MarkdownArticleGORMRepository struct {
 	db *gorm.DB
@@ -137,8 +260,14 @@ func (r *MarkdownArticleGORMRepository) Create(ctx context.Context, article *mod
 	return r.db.WithContext(ctx).Create(article).Error
 }
 
-func (r *MarkdownArticleGORMRepository) Update(ctx context.Context, article *model.MarkdownArticle) error {
-	return r.db.WithContext(ctx).Save(article).Error
+func (r *MarkdownArticleGORMRepository) Update(ctx context.Context, article *model.MarkdownArticle, expectedVersion int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(article).Where("version = ?", expectedVersion).Select("*").Updates(article))
+}
+
+func (r *MarkdownArticleGORMRepository) UpdateHTMLCache(ctx context.Context, article *model.MarkdownArticle) error {
+	return r.db.WithContext(ctx).Model(&model.MarkdownArticle{}).
+		Where("id = ? AND version = ?", article.ID, article.Version).
+		UpdateColumns(map[string]interface{}{"html_content": article.HTMLContent, "html_hash": article.HTMLHash}).Error
 }
 
 func (r *MarkdownArticleGORMRepository) FindByArticleID(ctx context.Context, articleID uint) (*model.MarkdownArticle, error) {
@@ -167,8 +296,8 @@ func (r *RichTextArticleGORMRepository) Create(ctx context.Context, article *mod
 	return r.db.WithContext(ctx).Create(article).Error
 }
 
-func (r *RichTextArticleGORMRepository) Update(ctx context.Context, article *model.RichTextArticle) error {
-	return r.db.WithContext(ctx).Save(article).Error
+func (r *RichTextArticleGORMRepository) Update(ctx context.Context, article *model.RichTextArticle, expectedVersion int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(article).Where("version = ?", expectedVersion).Select("*").Updates(article))
 }
 
 func (r *RichTextArticleGORMRepository) FindByArticleID(ctx context.Context, articleID uint) (*model.RichTextArticle, error) {
@@ -197,8 +326,41 @@ func (r *TableArticleGORMRepository) Create(ctx context.Context, article *model.
 	return r.db.WithContext(ctx).Create(article).Error
 }
 
-func (r *TableArticleGORMRepository) Update(ctx context.Context, article *model.TableArticle) error {
-	return r.db.WithContext(ctx).Save(article).Error
+func (r *TableArticleGORMRepository) Update(ctx context.Context, article *model.TableArticle, expectedVersion int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(article).Where("version = ?", expectedVersion).Select("*").Omit("data_version").Updates(article))
+}
+
+func (r *TableArticleGORMRepository) UpdateFilters(ctx context.Context, articleID uint, filters model.JSONArray) error {
+	return r.db.WithContext(ctx).Model(&model.TableArticle{}).
+		Where("article_id = ?", articleID).
+		Updates(map[string]interface{}{"filters": filters, "updated_at": time.Now()}).Error
+}
+
+func (r *TableArticleGORMRepository) BumpDataVersion(ctx context.Context, articleID uint, expectedVersion int) (int, error) {
+	q := r.db.WithContext(ctx).Model(&model.TableArticle{}).Where("article_id = ?", articleID)
+	if expectedVersion > 0 {
+		q = q.Where("data_version = ?", expectedVersion)
+	}
+	if err := updateVersioned(q.UpdateColumn("data_version", gorm.Expr("data_version + 1"))); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := r.db.WithContext(ctx).Model(&model.TableArticle{}).
+		Where("article_id = ?", articleID).
+		Pluck("data_version", &version).Error
+	return version, err
+}
+
+// updateVersioned 将条件更新未命中转换为 ErrStaleVersion
+func updateVersioned(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
 }
 
 func (r *TableArticleGORMRepository) FindByArticleID(ctx context.Context, articleID uint) (*model.TableArticle, error) {
@@ -249,6 +411,16 @@ func (r *TableArticleRowGORMRepository) FindByArticleID(ctx context.Context, art
 	return rows, err
 }
 
+func (r *TableArticleRowGORMRepository) FindBatch(ctx context.Context, articleID uint, offset, limit int) ([]model.TableArticleRow, error) {
+	var rows []model.TableArticleRow
+	err := r.db.WithContext(ctx).
+		Where("article_id = ?", articleID).
+		Order("row_index ASC, id ASC").
+		Offset(offset).Limit(limit).
+		Find(&rows).Error
+	return rows, err
+}
+
 func (r *TableArticleRowGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
 	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.TableArticleRow{}).Error
 }
@@ -266,3 +438,645 @@ func (r *TableArticleRowGORMRepository) ReplaceAll(ctx context.Context, articleI
 		return nil
 	})
 }
+
+func (r *TableArticleRowGORMRepository) FindByID(ctx context.Context, id uint) (*model.TableArticleRow, error) {
+	var row model.TableArticleRow
+	if err := r.db.WithContext(ctx).First(&row, id).Error; err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (r *TableArticleRowGORMRepository) FindByIDs(ctx context.Context, articleID uint, ids []uint) ([]model.TableArticleRow, error) {
+	var rows []model.TableArticleRow
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	err := r.db.WithContext(ctx).Where("article_id = ? AND id IN ?", articleID, ids).Find(&rows).Error
+	return rows, err
+}
+
+func (r *TableArticleRowGORMRepository) Count(ctx context.Context, articleID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.TableArticleRow{}).Where("article_id = ?", articleID).Count(&count).Error
+	return count, err
+}
+
+func (r *TableArticleRowGORMRepository) Update(ctx context.Context, row *model.TableArticleRow) error {
+	return r.db.WithContext(ctx).Save(row).Error
+}
+
+func (r *TableArticleRowGORMRepository) DeleteByIDs(ctx context.Context, articleID uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("article_id = ? AND id IN ?", articleID, ids).Delete(&model.TableArticleRow{}).Error
+}
+
+func (r *TableArticleRowGORMRepository) ShiftRowIndexes(ctx context.Context, articleID uint, from, to, delta int) error {
+	q := r.db.WithContext(ctx).Model(&model.TableArticleRow{}).
+		Where("article_id = ? AND row_index >= ?", articleID, from)
+	if to >= 0 {
+		q = q.Where("row_index <= ?", to)
+	}
+	return q.UpdateColumn("row_index", gorm.Expr("row_index + ?", delta)).Error
+}
+
+// MarkdownOperationGORMRepository GORM Markdown 协同编辑操作日志仓储实现
+type MarkdownOperationGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewMarkdownOperationGORMRepository(db *gorm.DB) *MarkdownOperationGORMRepository {
+	return &MarkdownOperationGORMRepository{db: db}
+}
+
+func (r *MarkdownOperationGORMRepository) Create(ctx context.Context, op *model.MarkdownOperation) error {
+	return r.db.WithContext(ctx).Create(op).Error
+}
+
+func (r *MarkdownOperationGORMRepository) FindSince(ctx context.Context, articleID uint, version, limit int) ([]model.MarkdownOperation, error) {
+	var ops []model.MarkdownOperation
+	err := r.db.WithContext(ctx).
+		Where("article_id = ? AND version > ?", articleID, version).
+		Order("version ASC").
+		Limit(limit).
+		Find(&ops).Error
+	return ops, err
+}
+
+func (r *MarkdownOperationGORMRepository) DeleteBefore(ctx context.Context, articleID uint, version int) error {
+	return r.db.WithContext(ctx).Where("article_id = ? AND version <= ?", articleID, version).Delete(&model.MarkdownOperation{}).Error
+}
+
+func (r *MarkdownOperationGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.MarkdownOperation{}).Error
+}
+
+// ArticleEditLockGORMRepository GORM 文章编辑锁仓储实现
+type ArticleEditLockGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleEditLockGORMRepository(db *gorm.DB) *ArticleEditLockGORMRepository {
+	return &ArticleEditLockGORMRepository{db: db}
+}
+
+func (r *ArticleEditLockGORMRepository) FindByArticleID(ctx context.Context, articleID uint) (*model.ArticleEditLock, error) {
+	var lock model.ArticleEditLock
+	err := r.db.WithContext(ctx).Where("article_id = ?", articleID).First(&lock).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (r *ArticleEditLockGORMRepository) FindByArticleIDForShare(ctx context.Context, articleID uint) (*model.ArticleEditLock, error) {
+	var lock model.ArticleEditLock
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthShare}).
+		Where("article_id = ?", articleID).
+		First(&lock).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (r *ArticleEditLockGORMRepository) Create(ctx context.Context, lock *model.ArticleEditLock) error {
+	return r.db.WithContext(ctx).Create(lock).Error
+}
+
+func (r *ArticleEditLockGORMRepository) Update(ctx context.Context, lock *model.ArticleEditLock, expectedVersion int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(lock).Where("version = ?", expectedVersion).Select("*").Updates(lock))
+}
+
+func (r *ArticleEditLockGORMRepository) Delete(ctx context.Context, id uint, version int) error {
+	return updateVersioned(r.db.WithContext(ctx).Where("id = ? AND version = ?", id, version).Delete(&model.ArticleEditLock{}))
+}
+
+func (r *ArticleEditLockGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.ArticleEditLock{}).Error
+}
+
+// ArticleRevisionGORMRepository GORM 内容修订记录仓储实现
+type ArticleRevisionGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleRevisionGORMRepository(db *gorm.DB) *ArticleRevisionGORMRepository {
+	return &ArticleRevisionGORMRepository{db: db}
+}
+
+func (r *ArticleRevisionGORMRepository) Create(ctx context.Context, revision *model.ArticleRevision) error {
+	return r.db.WithContext(ctx).Create(revision).Error
+}
+
+func (r *ArticleRevisionGORMRepository) PaginateByArticleID(ctx context.Context, articleID uint, page, pageSize int) ([]model.ArticleRevision, int64, error) {
+	var revisions []model.ArticleRevision
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ArticleRevision{}).Where("article_id = ?", articleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Omit("content").Offset(offset).Limit(pageSize).Order("sequence DESC").Find(&revisions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+func (r *ArticleRevisionGORMRepository) FindBySequence(ctx context.Context, articleID uint, sequence int) (*model.ArticleRevision, error) {
+	var revision model.ArticleRevision
+	err := r.db.WithContext(ctx).Where("article_id = ? AND sequence = ?", articleID, sequence).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *ArticleRevisionGORMRepository) MaxSequence(ctx context.Context, articleID uint) (int, error) {
+	var max int
+	err := r.db.WithContext(ctx).Model(&model.ArticleRevision{}).
+		Where("article_id = ?", articleID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&max).Error
+	return max, err
+}
+
+func (r *ArticleRevisionGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.ArticleRevision{}).Error
+}
+
+// TableArticleStructureHistoryGORMRepository GORM 表格结构变更历史仓储实现
+type TableArticleStructureHistoryGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewTableArticleStructureHistoryGORMRepository(db *gorm.DB) *TableArticleStructureHistoryGORMRepository {
+	return &TableArticleStructureHistoryGORMRepository{db: db}
+}
+
+func (r *TableArticleStructureHistoryGORMRepository) Create(ctx context.Context, history *model.TableArticleStructureHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+func (r *TableArticleStructureHistoryGORMRepository) FindByArticleID(ctx context.Context, articleID uint) ([]model.TableArticleStructureHistory, error) {
+	var histories []model.TableArticleStructureHistory
+	err := r.db.WithContext(ctx).Where("article_id = ?", articleID).Order("version DESC").Find(&histories).Error
+	return histories, err
+}
+
+func (r *TableArticleStructureHistoryGORMRepository) FindByVersion(ctx context.Context, articleID uint, version int) (*model.TableArticleStructureHistory, error) {
+	var history model.TableArticleStructureHistory
+	err := r.db.WithContext(ctx).Where("article_id = ? AND version = ?", articleID, version).First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+func (r *TableArticleStructureHistoryGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.TableArticleStructureHistory{}).Error
+}
+
+// ArticleGrantGORMRepository GORM 访问授权仓储实现
+type ArticleGrantGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleGrantGORMRepository(db *gorm.DB) *ArticleGrantGORMRepository {
+	return &ArticleGrantGORMRepository{db: db}
+}
+
+func (r *ArticleGrantGORMRepository) Upsert(ctx context.Context, grant *model.ArticleGrant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.ArticleGrant
+		err := tx.Where("resource_type = ? AND resource_id = ? AND subject_type = ? AND subject_id = ?",
+			grant.ResourceType, grant.ResourceID, grant.SubjectType, grant.SubjectID).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(grant).Error
+		}
+		if err != nil {
+			return err
+		}
+		grant.ID = existing.ID
+		grant.CreatedAt = existing.CreatedAt
+		return tx.Save(grant).Error
+	})
+}
+
+func (r *ArticleGrantGORMRepository) Delete(ctx context.Context, resourceType string, resourceID uint, subjectType string, subjectID uint) error {
+	return r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ? AND subject_type = ? AND subject_id = ?", resourceType, resourceID, subjectType, subjectID).
+		Delete(&model.ArticleGrant{}).Error
+}
+
+func (r *ArticleGrantGORMRepository) FindByResource(ctx context.Context, resourceType string, resourceID uint) ([]model.ArticleGrant, error) {
+	var grants []model.ArticleGrant
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Order("id ASC").
+		Find(&grants).Error
+	return grants, err
+}
+
+func (r *ArticleGrantGORMRepository) FindForPrincipal(ctx context.Context, resourceType string, resourceIDs []uint, userID uint, teamIDs []uint) ([]model.ArticleGrant, error) {
+	var grants []model.ArticleGrant
+	if len(resourceIDs) == 0 {
+		return grants, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id IN ?", resourceType, resourceIDs).
+		Where(grantSubjectCondition(r.db, userID, teamIDs)).
+		Find(&grants).Error
+	return grants, err
+}
+
+func (r *ArticleGrantGORMRepository) DeleteByResource(ctx context.Context, resourceType string, resourceID uint) error {
+	return r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Delete(&model.ArticleGrant{}).Error
+}
+
+// grantSubjectCondition 授权对象为用户本人或其所属团队
+func grantSubjectCondition(db *gorm.DB, userID uint, teamIDs []uint) *gorm.DB {
+	cond := db.Session(&gorm.Session{NewDB: true}).Where("subject_type = ? AND subject_id = ?", model.SubjectTypeUser, userID)
+	if len(teamIDs) > 0 {
+		cond = cond.Or("subject_type = ? AND subject_id IN ?", model.SubjectTypeTeam, teamIDs)
+	}
+	return cond
+}
+
+// ArticleShareLinkGORMRepository GORM 分享链接仓储实现
+type ArticleShareLinkGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleShareLinkGORMRepository(db *gorm.DB) *ArticleShareLinkGORMRepository {
+	return &ArticleShareLinkGORMRepository{db: db}
+}
+
+func (r *ArticleShareLinkGORMRepository) Create(ctx context.Context, link *model.ArticleShareLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *ArticleShareLinkGORMRepository) FindByID(ctx context.Context, id uint) (*model.ArticleShareLink, error) {
+	var link model.ArticleShareLink
+	if err := r.db.WithContext(ctx).First(&link, id).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *ArticleShareLinkGORMRepository) FindByToken(ctx context.Context, token string) (*model.ArticleShareLink, error) {
+	var link model.ArticleShareLink
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *ArticleShareLinkGORMRepository) FindByArticleID(ctx context.Context, articleID uint) ([]model.ArticleShareLink, error) {
+	var links []model.ArticleShareLink
+	err := r.db.WithContext(ctx).
+		Where("article_id = ?", articleID).
+		Order("id DESC").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *ArticleShareLinkGORMRepository) IncrementViewCount(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.ArticleShareLink{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_views = 0 OR view_count < max_views)", id).
+		UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ArticleShareLinkGORMRepository) ReservePasswordAttempt(ctx context.Context, id uint, now time.Time, maxFailures int, lockout time.Duration) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.ArticleShareLink{}).
+		Where("id = ? AND (failed_count < ? OR last_failed_at IS NULL OR last_failed_at <= ?)", id, maxFailures, now.Add(-lockout)).
+		UpdateColumns(map[string]interface{}{
+			"failed_count":   gorm.Expr("failed_count + 1"),
+			"last_failed_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ArticleShareLinkGORMRepository) ResetPasswordFailures(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.ArticleShareLink{}).
+		Where("id = ? AND failed_count > 0", id).
+		UpdateColumn("failed_count", 0).Error
+}
+
+func (r *ArticleShareLinkGORMRepository) Revoke(ctx context.Context, id uint, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.ArticleShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": revokedAt, "updated_at": revokedAt}).Error
+}
+
+func (r *ArticleShareLinkGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.ArticleShareLink{}).Error
+}
+
+// TagGORMRepository GORM 标签仓储实现
+type TagGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewTagGORMRepository(db *gorm.DB) *TagGORMRepository {
+	return &TagGORMRepository{db: db}
+}
+
+func (r *TagGORMRepository) Create(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+func (r *TagGORMRepository) Update(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Save(tag).Error
+}
+
+func (r *TagGORMRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Tag{}, id).Error
+}
+
+func (r *TagGORMRepository) FindByID(ctx context.Context, id uint) (*model.Tag, error) {
+	var tag model.Tag
+	if err := r.db.WithContext(ctx).First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *TagGORMRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&tags).Error
+	return tags, err
+}
+
+func (r *TagGORMRepository) FindByNames(ctx context.Context, ownerType string, ownerID uint, names []string) ([]model.Tag, error) {
+	var tags []model.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ? AND name IN ?", ownerType, ownerID, names).
+		Find(&tags).Error
+	return tags, err
+}
+
+func (r *TagGORMRepository) FindByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+func (r *TagGORMRepository) AddToArticle(ctx context.Context, articleID uint, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	links := make([]model.ArticleTag, len(tagIDs))
+	for i, tagID := range tagIDs {
+		links[i] = model.ArticleTag{ArticleID: articleID, TagID: tagID, CreatedAt: now}
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+func (r *TagGORMRepository) RemoveFromArticle(ctx context.Context, articleID uint, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("article_id = ? AND tag_id IN ?", articleID, tagIDs).
+		Delete(&model.ArticleTag{}).Error
+}
+
+func (r *TagGORMRepository) FindByArticleID(ctx context.Context, articleID uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.WithContext(ctx).
+		Joins("JOIN article_tags ON article_tags.tag_id = tags.id").
+		Where("article_tags.article_id = ?", articleID).
+		Order("tags.name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+func (r *TagGORMRepository) MoveArticles(ctx context.Context, fromTagID, toTagID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var articleIDs []uint
+		if err := tx.Model(&model.ArticleTag{}).Where("tag_id = ?", fromTagID).Pluck("article_id", &articleIDs).Error; err != nil {
+			return err
+		}
+		if len(articleIDs) > 0 {
+			now := time.Now()
+			links := make([]model.ArticleTag, len(articleIDs))
+			for i, id := range articleIDs {
+				links[i] = model.ArticleTag{ArticleID: id, TagID: toTagID, CreatedAt: now}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("tag_id = ?", fromTagID).Delete(&model.ArticleTag{}).Error
+	})
+}
+
+func (r *TagGORMRepository) DeleteArticleTagsByTagID(ctx context.Context, tagID uint) error {
+	return r.db.WithContext(ctx).Where("tag_id = ?", tagID).Delete(&model.ArticleTag{}).Error
+}
+
+func (r *TagGORMRepository) DeleteArticleTagsByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.ArticleTag{}).Error
+}
+
+func (r *TagGORMRepository) CountArticles(ctx context.Context, ownerType string, ownerID uint) (map[uint]int64, error) {
+	var rows []struct {
+		TagID uint
+		Count int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.ArticleTag{}).
+		Select("article_tags.tag_id AS tag_id, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = article_tags.tag_id").
+		Joins("JOIN articles ON articles.id = article_tags.article_id").
+		Where("tags.owner_type = ? AND tags.owner_id = ? AND articles.status != ?", ownerType, ownerID, model.StatusDeleted).
+		Group("article_tags.tag_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TagID] = row.Count
+	}
+	return counts, nil
+}
+
+// ArticleCommentGORMRepository GORM 评论仓储实现
+type ArticleCommentGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleCommentGORMRepository(db *gorm.DB) *ArticleCommentGORMRepository {
+	return &ArticleCommentGORMRepository{db: db}
+}
+
+func (r *ArticleCommentGORMRepository) Create(ctx context.Context, comment *model.ArticleComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *ArticleCommentGORMRepository) Update(ctx context.Context, comment *model.ArticleComment) error {
+	return r.db.WithContext(ctx).Save(comment).Error
+}
+
+func (r *ArticleCommentGORMRepository) FindByID(ctx context.Context, id uint) (*model.ArticleComment, error) {
+	var comment model.ArticleComment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *ArticleCommentGORMRepository) PaginateRoots(ctx context.Context, articleID uint, page, pageSize int) ([]model.ArticleComment, int64, error) {
+	var comments []model.ArticleComment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ArticleComment{}).Where("article_id = ? AND parent_id IS NULL", articleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("created_at ASC, id ASC").Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+func (r *ArticleCommentGORMRepository) FindByRootIDs(ctx context.Context, rootIDs []uint) ([]model.ArticleComment, error) {
+	var comments []model.ArticleComment
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("root_id IN ?", rootIDs).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *ArticleCommentGORMRepository) CreateEdit(ctx context.Context, edit *model.ArticleCommentEdit) error {
+	return r.db.WithContext(ctx).Create(edit).Error
+}
+
+func (r *ArticleCommentGORMRepository) FindEdits(ctx context.Context, commentID uint) ([]model.ArticleCommentEdit, error) {
+	var edits []model.ArticleCommentEdit
+	err := r.db.WithContext(ctx).
+		Where("comment_id = ?", commentID).
+		Order("id DESC").
+		Find(&edits).Error
+	return edits, err
+}
+
+func (r *ArticleCommentGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		commentIDs := tx.Session(&gorm.Session{NewDB: true}).Model(&model.ArticleComment{}).Select("id").Where("article_id = ?", articleID)
+		if err := tx.Where("comment_id IN (?)", commentIDs).Delete(&model.ArticleCommentEdit{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("article_id = ?", articleID).Delete(&model.ArticleComment{}).Error
+	})
+}
+
+// ArticleAttachmentGORMRepository GORM 附件仓储实现
+type ArticleAttachmentGORMRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleAttachmentGORMRepository(db *gorm.DB) *ArticleAttachmentGORMRepository {
+	return &ArticleAttachmentGORMRepository{db: db}
+}
+
+func (r *ArticleAttachmentGORMRepository) Create(ctx context.Context, attachment *model.ArticleAttachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (r *ArticleAttachmentGORMRepository) FindByID(ctx context.Context, id uint) (*model.ArticleAttachment, error) {
+	var attachment model.ArticleAttachment
+	if err := r.db.WithContext(ctx).First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *ArticleAttachmentGORMRepository) FindByArticleID(ctx context.Context, articleID uint) ([]model.ArticleAttachment, error) {
+	var attachments []model.ArticleAttachment
+	err := r.db.WithContext(ctx).
+		Where("article_id = ?", articleID).
+		Order("id ASC").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *ArticleAttachmentGORMRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.ArticleAttachment{}, id).Error
+}
+
+func (r *ArticleAttachmentGORMRepository) DeleteByArticleID(ctx context.Context, articleID uint) error {
+	return r.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&model.ArticleAttachment{}).Error
+}
+
+func (r *ArticleAttachmentGORMRepository) CreateBlob(ctx context.Context, blob *model.ArticleBlob) error {
+	return r.db.WithContext(ctx).Create(blob).Error
+}
+
+func (r *ArticleAttachmentGORMRepository) FindBlobByID(ctx context.Context, id uint) (*model.ArticleBlob, error) {
+	var blob model.ArticleBlob
+	if err := r.db.WithContext(ctx).First(&blob, id).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (r *ArticleAttachmentGORMRepository) FindBlobByHash(ctx context.Context, hash string) (*model.ArticleBlob, error) {
+	var blob model.ArticleBlob
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (r *ArticleAttachmentGORMRepository) AddBlobRef(ctx context.Context, id uint, delta int) error {
+	return updateVersioned(r.db.WithContext(ctx).Model(&model.ArticleBlob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count + ?", delta),
+			"updated_at": time.Now(),
+		}))
+}
+
+func (r *ArticleAttachmentGORMRepository) DeleteBlobIfUnreferenced(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND ref_count <= 0", id).Delete(&model.ArticleBlob{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,356 @@
+package article
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 策略名称常量
+const (
+	SanitizePolicyStrict     = "strict"
+	SanitizePolicyStandard   = "standard"
+	SanitizePolicyPermissive = "permissive"
+)
+
+// SanitizePolicy 富文本 HTML 白名单策略
+type SanitizePolicy struct {
+	Name string
+	// AllowedTags 允许保留的标签；未列出的标签会被去掉但保留其文本
+	AllowedTags map[string]bool
+	// AllowedAttributes 按标签允许的属性，键 "*" 表示所有标签通用
+	AllowedAttributes map[string]map[string]bool
+	// AllowedURLSchemes 链接类属性允许的协议（相对链接始终允许）
+	AllowedURLSchemes map[string]bool
+	// AllowedStyleProperties 允许的内联样式属性；为空时移除整个 style 属性
+	AllowedStyleProperties map[string]bool
+}
+
+// SanitizeRemoval 一类被移除的内容
+type SanitizeRemoval struct {
+	Kind  string `json:"kind"` // tag, attribute, url, style, comment
+	Tag   string `json:"tag"`
+	Name  string `json:"name,omitempty"` // 属性名或样式属性名
+	Count int    `json:"count"`
+}
+
+// SanitizeReport 清洗报告，供编辑器提示用户哪些内容被移除
+type SanitizeReport struct {
+	Policy  string            `json:"policy"`
+	Removed []SanitizeRemoval `json:"removed"`
+}
+
+// Modified 内容是否被修改
+func (r *SanitizeReport) Modified() bool {
+	return len(r.Removed) > 0
+}
+
+func (r *SanitizeReport) add(kind, tag, name string) {
+	for i := range r.Removed {
+		if rm := &r.Removed[i]; rm.Kind == kind && rm.Tag == tag && rm.Name == name {
+			rm.Count++
+			return
+		}
+	}
+	r.Removed = append(r.Removed, SanitizeRemoval{Kind: kind, Tag: tag, Name: name, Count: 1})
+}
+
+// 连同内容整体丢弃的元素（无论策略如何）
+var dropContentTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "frameset": true, "frame": true, "applet": true,
+}
+
+// 无结束标签的元素
+var voidTags = map[string]bool{
+	"br": true, "hr": true, "img": true, "col": true, "source": true, "wbr": true, "track": true,
+}
+
+// 需要校验协议的链接类属性
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true, "action": true, "longdesc": true,
+}
+
+// Sanitize 按策略清洗 HTML，返回清洗结果与报告
+func (p *SanitizePolicy) Sanitize(input string) (string, *SanitizeReport) {
+	report := &SanitizeReport{Policy: p.Name}
+	var out strings.Builder
+	var open []string // 已输出且未闭合的标签
+	skipTag, skipDepth := "", 0
+
+	z := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF 或不可恢复的解析错误，剩余内容一律丢弃
+			break
+		}
+		token := z.Token()
+
+		// 处于整体丢弃的元素内部
+		if skipTag != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == skipTag:
+				skipDepth++
+			case tt == html.EndTagToken && token.Data == skipTag:
+				skipDepth--
+				if skipDepth == 0 {
+					skipTag = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+
+		case html.CommentToken:
+			report.add("comment", "", "")
+
+		case html.DoctypeToken:
+			// 片段内容不需要 doctype
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropContentTags[token.Data] {
+				report.add("tag", token.Data, "")
+				if tt == html.StartTagToken && !voidTags[token.Data] {
+					skipTag, skipDepth = token.Data, 1
+				}
+				continue
+			}
+			if !p.AllowedTags[token.Data] {
+				report.add("tag", token.Data, "")
+				continue
+			}
+			out.WriteString("<" + token.Data)
+			for _, attr := range p.sanitizeAttributes(token.Data, token.Attr, report) {
+				out.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+			if tt == html.SelfClosingTagToken || voidTags[token.Data] {
+				out.WriteString("/>")
+				continue
+			}
+			out.WriteString(">")
+			open = append(open, token.Data)
+
+		case html.EndTagToken:
+			if !p.AllowedTags[token.Data] || voidTags[token.Data] {
+				continue
+			}
+			// 只闭合确实打开过的标签，中间未闭合的一并闭合
+			idx := -1
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Data {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				continue
+			}
+			for i := len(open) - 1; i >= idx; i-- {
+				out.WriteString("</" + open[i] + ">")
+			}
+			open = open[:idx]
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String(), report
+}
+
+// sanitizeAttributes 过滤标签属性
+func (p *SanitizePolicy) sanitizeAttributes(tag string, attrs []html.Attribute, report *SanitizeReport) []html.Attribute {
+	var kept []html.Attribute
+	hasBlankTarget := false
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !(p.AllowedAttributes[tag][key] || p.AllowedAttributes["*"][key]) {
+			report.add("attribute", tag, key)
+			continue
+		}
+
+		switch {
+		case key == "style":
+			style := p.sanitizeStyle(tag, attr.Val, report)
+			if style == "" {
+				continue
+			}
+			attr.Val = style
+		case urlAttributes[key]:
+			if !p.allowURL(attr.Val) {
+				report.add("url", tag, key)
+				continue
+			}
+		case key == "target":
+			hasBlankTarget = strings.EqualFold(attr.Val, "_blank")
+		case key == "rel":
+			// rel 由下方统一补齐
+			continue
+		}
+		attr.Key = key
+		kept = append(kept, attr)
+	}
+
+	// 新窗口打开的链接禁止访问 window.opener
+	if tag == "a" && hasBlankTarget {
+		kept = append(kept, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	}
+	return kept
+}
+
+// sanitizeStyle 过滤内联样式，只保留白名单属性
+func (p *SanitizePolicy) sanitizeStyle(tag, style string, report *SanitizeReport) string {
+	var kept []string
+	for _, decl := range strings.Split(style, ";") {
+		decl = strings.TrimSpace(decl)
+		if decl == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(decl, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !ok || !p.AllowedStyleProperties[name] || !safeStyleValue(value) {
+			report.add("style", tag, name)
+			continue
+		}
+		kept = append(kept, name+": "+value)
+	}
+	return strings.Join(kept, "; ")
+}
+
+// allowURL 校验链接协议
+func (p *SanitizePolicy) allowURL(raw string) bool {
+	// 去掉浏览器会忽略的空白与控制字符，防止 "java\tscript:" 之类的绕过
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 {
+		return true
+	}
+	// 冒号出现在路径、查询或锚点之后，属于相对链接
+	if i := strings.IndexAny(cleaned, "/?#"); i >= 0 && i < colon {
+		return true
+	}
+	return p.AllowedURLSchemes[strings.ToLower(cleaned[:colon])]
+}
+
+func safeStyleValue(value string) bool {
+	v := strings.ToLower(value)
+	for _, bad := range []string{"expression(", "url(", "javascript:", "\\", "<", "@import", "behavior"} {
+		if strings.Contains(v, bad) {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// StrictPolicy 严格策略：仅基础文本格式与链接，不允许图片和样式
+func StrictPolicy() *SanitizePolicy {
+	return newSanitizePolicy(SanitizePolicyStrict,
+		[]string{"p", "br", "strong", "b", "em", "i", "u", "s", "a", "ul", "ol", "li", "blockquote", "code", "pre"},
+		map[string][]string{
+			"a": {"href", "title"},
+		},
+		[]string{"http", "https", "mailto"},
+		nil,
+	)
+}
+
+// StandardPolicy 标准策略（默认）：常见排版、图片、表格与少量样式
+func StandardPolicy() *SanitizePolicy {
+	return newSanitizePolicy(SanitizePolicyStandard,
+		[]string{
+			"p", "br", "hr", "div", "span", "strong", "b", "em", "i", "u", "s", "del", "ins", "sub", "sup", "mark",
+			"h1", "h2", "h3", "h4", "h5", "h6", "a", "img", "ul", "ol", "li", "blockquote", "code", "pre",
+			"table", "thead", "tbody", "tfoot", "tr", "th", "td", "caption", "figure", "figcaption",
+		},
+		map[string][]string{
+			"*":   {"class", "style"},
+			"a":   {"href", "title", "target"},
+			"img": {"src", "alt", "title", "width", "height"},
+			"th":  {"colspan", "rowspan", "align"},
+			"td":  {"colspan", "rowspan", "align"},
+			"ol":  {"start"},
+		},
+		[]string{"http", "https", "mailto", "tel"},
+		[]string{"color", "background-color", "text-align", "font-weight", "font-style", "text-decoration"},
+	)
+}
+
+// PermissivePolicy 宽松策略：在标准策略基础上允许音视频与更多排版样式
+func PermissivePolicy() *SanitizePolicy {
+	return newSanitizePolicy(SanitizePolicyPermissive,
+		[]string{
+			"p", "br", "hr", "div", "span", "section", "article", "header", "footer", "nav", "aside",
+			"strong", "b", "em", "i", "u", "s", "del", "ins", "sub", "sup", "mark", "small", "abbr", "kbd", "q", "cite",
+			"h1", "h2", "h3", "h4", "h5", "h6", "a", "img", "ul", "ol", "li", "dl", "dt", "dd", "blockquote", "code", "pre",
+			"table", "thead", "tbody", "tfoot", "tr", "th", "td", "caption", "colgroup", "col", "figure", "figcaption",
+			"video", "audio", "source", "details", "summary",
+		},
+		map[string][]string{
+			"*":       {"class", "style", "id", "title", "lang", "dir"},
+			"a":       {"href", "target", "name"},
+			"img":     {"src", "alt", "width", "height"},
+			"th":      {"colspan", "rowspan", "align", "scope"},
+			"td":      {"colspan", "rowspan", "align"},
+			"col":     {"span", "width"},
+			"ol":      {"start", "type", "reversed"},
+			"q":       {"cite"},
+			"video":   {"src", "poster", "controls", "width", "height", "loop", "muted"},
+			"audio":   {"src", "controls", "loop", "muted"},
+			"source":  {"src", "type"},
+			"details": {"open"},
+		},
+		[]string{"http", "https", "mailto", "tel"},
+		[]string{
+			"color", "background-color", "text-align", "font-weight", "font-style", "text-decoration",
+			"font-size", "font-family", "line-height", "letter-spacing", "vertical-align", "white-space",
+			"width", "height", "max-width", "margin", "margin-top", "margin-bottom", "margin-left", "margin-right",
+			"padding", "padding-top", "padding-bottom", "padding-left", "padding-right",
+			"border", "border-color", "border-width", "border-style", "border-collapse", "list-style-type",
+		},
+	)
+}
+
+// PolicyByName 按名称获取内置策略
+func PolicyByName(name string) (*SanitizePolicy, bool) {
+	switch name {
+	case SanitizePolicyStrict:
+		return StrictPolicy(), true
+	case SanitizePolicyStandard:
+		return StandardPolicy(), true
+	case SanitizePolicyPermissive:
+		return PermissivePolicy(), true
+	}
+	return nil, false
+}
+
+func newSanitizePolicy(name string, tags []string, attrs map[string][]string, schemes, styles []string) *SanitizePolicy {
+	p := &SanitizePolicy{
+		Name:                   name,
+		AllowedTags:            toSet(tags),
+		AllowedAttributes:      make(map[string]map[string]bool, len(attrs)),
+		AllowedURLSchemes:      toSet(schemes),
+		AllowedStyleProperties: toSet(styles),
+	}
+	for tag, names := range attrs {
+		p.AllowedAttributes[tag] = toSet(names)
+	}
+	return p
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,156 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+)
+
+// ==================== 定时发布 ====================
+//
+// 文章的 PublishAt / UnpublishAt 到期后由 RunDueTransitions 切换状态并分发 article:published / article:unpublished 事件。
+// RunDueTransitions 可以由外部任务（如 cron）直接调用，也可以交给 Scheduler 按固定间隔驱动；
+// 状态切换以读取时的状态为条件写入，多个实例同时运行时每次切换只会生效一次。
+
+// dueTransitionBatch 每批处理的到期文章数
+const dueTransitionBatch = 100
+
+// RunDueTransitions 执行截至 now 到期的定时发布与下线，返回发生状态切换的文章数
+// 单篇文章失败时记录日志并继续处理其余文章，最后返回遇到的第一个错误。
+func (s *Service) RunDueTransitions(ctx context.Context, now time.Time) (int, error) {
+	var fired int
+	var firstErr error
+	var afterID uint
+	for {
+		articles, err := s.articleRepo.FindDueTransitions(ctx, now, afterID, dueTransitionBatch)
+		if err != nil {
+			s.logger.ErrorCtx(ctx, "查询到期定时任务失败", zap.Error(err))
+			return fired, ErrDatabaseError.Wrap(err)
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			afterID = article.ID
+			ok, err := s.applyDueTransition(ctx, article, now)
+			if err != nil {
+				s.logger.ErrorCtx(ctx, "执行定时发布失败", zap.Uint("article_id", article.ID), zap.Error(err))
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if ok {
+				fired++
+			}
+		}
+
+		if len(articles) < dueTransitionBatch {
+			return fired, firstErr
+		}
+	}
+}
+
+// applyDueTransition 切换单篇文章的状态；同一时刻发布与下线都已到期时两者依次生效
+// 文章已被其他实例或手动操作改变状态时返回 false。
+func (s *Service) applyDueTransition(ctx context.Context, article *model.Article, now time.Time) (bool, error) {
+	fromStatus := article.Status
+	published, unpublished := false, false
+	if article.PublishDue(now) {
+		article.Status = model.StatusPublished
+		article.PublishAt = nil
+		published = true
+	}
+	if article.UnpublishDue(now) {
+		article.Status = model.StatusDraft
+		article.UnpublishAt = nil
+		unpublished = true
+	}
+	if !published && !unpublished {
+		return false, nil
+	}
+	article.UpdatedAt = now
+
+	if err := s.articleRepo.UpdateSchedule(ctx, article, fromStatus); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return false, nil
+		}
+		return false, ErrDatabaseError.Wrap(err)
+	}
+
+	if published {
+		s.dispatchAsync(ctx, NewArticlePublicationEvent(article.ID, article.FolderID, true, true))
+	}
+	if unpublished {
+		s.dispatchAsync(ctx, NewArticlePublicationEvent(article.ID, article.FolderID, false, true))
+	}
+	s.indexArticle(ctx, article.ID)
+	s.logger.InfoCtx(ctx, "定时发布状态切换",
+		zap.Uint("article_id", article.ID),
+		zap.Int("from_status", fromStatus),
+		zap.Int("to_status", article.Status))
+	return true, nil
+}
+
+// Scheduler 按固定间隔调用 RunDueTransitions 的调度器
+type Scheduler struct {
+	svc      *Service
+	interval time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScheduler 创建定时发布调度器，interval <= 0 时默认每分钟执行一次
+func NewScheduler(svc *Service, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Scheduler{svc: svc, interval: interval}
+}
+
+// Start 在后台启动调度，立即执行一次后按间隔执行；ctx 取消或调用 Stop 后退出，重复调用无效
+func (sc *Scheduler) Start(ctx context.Context) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.cancel != nil {
+		return
+	}
+
+	ctx, sc.cancel = context.WithCancel(ctx)
+	sc.done = make(chan struct{})
+	go sc.loop(ctx, sc.done)
+}
+
+// Stop 停止调度并等待正在执行的一轮结束
+func (sc *Scheduler) Stop() {
+	sc.mu.Lock()
+	cancel, done := sc.cancel, sc.done
+	sc.cancel, sc.done = nil, nil
+	sc.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (sc *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+
+	for {
+		// 错误已在 RunDueTransitions 中逐篇记录，未切换成功的文章下一轮会再次处理
+		_, _ = sc.svc.RunDueTransitions(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
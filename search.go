@@ -0,0 +1,370 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+// SearchDocument 待索引的文章文档
+type SearchDocument struct {
+	ArticleID   uint
+	Title       string
+	Body        string // 纯文本正文
+	ArticleType string
+	OwnerID     uint
+	OwnerType   string
+	FolderID    *uint
+}
+
+// SearchQuery 搜索条件
+type SearchQuery struct {
+	Text        string
+	OwnerID     *uint
+	OwnerType   string
+	ArticleType string
+	FolderIDs   []uint
+	Offset      int
+	Limit       int // 默认 DefaultPageSize，最大 MaxPageSize
+}
+
+// normalize 校验分页参数并填充默认值
+func (q *SearchQuery) normalize() error {
+	if q.Offset < 0 {
+		return ErrBadRequest.WithMsg("偏移量不能为负数")
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return ErrBadRequest.WithMsgf("每页数量必须在 1 到 %d 之间", MaxPageSize)
+	}
+	return nil
+}
+
+// matches 过滤条件是否命中文档
+func (q *SearchQuery) matches(doc *SearchDocument) bool {
+	if q.OwnerID != nil && doc.OwnerID != *q.OwnerID {
+		return false
+	}
+	if q.OwnerType != "" && doc.OwnerType != q.OwnerType {
+		return false
+	}
+	if q.ArticleType != "" && doc.ArticleType != q.ArticleType {
+		return false
+	}
+	if len(q.FolderIDs) > 0 {
+		if doc.FolderID == nil {
+			return false
+		}
+		found := false
+		for _, id := range q.FolderIDs {
+			if id == *doc.FolderID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchHit 索引命中结果
+type SearchHit struct {
+	ArticleID      uint    `json:"articleId"`
+	Score          float64 `json:"score"`
+	TitleHighlight string  `json:"titleHighlight"` // 已转义的 HTML，命中词以 <mark> 标出
+	Snippet        string  `json:"snippet"`        // 已转义的正文摘要
+}
+
+// SearchIndex 全文检索索引
+type SearchIndex interface {
+	// Index 新增或覆盖文档
+	Index(ctx context.Context, doc *SearchDocument) error
+	Remove(ctx context.Context, articleID uint) error
+	// Search 返回按相关度排序的分页命中与命中总数（Limit 为 0 时返回全部命中）
+	Search(ctx context.Context, query *SearchQuery) ([]SearchHit, int, error)
+}
+
+// SearchResultItem 搜索结果条目
+type SearchResultItem struct {
+	Article        *model.Article `json:"article"`
+	Score          float64        `json:"score"`
+	TitleHighlight string         `json:"titleHighlight"`
+	Snippet        string         `json:"snippet"`
+}
+
+// SearchResult 搜索结果
+type SearchResult struct {
+	Records []SearchResultItem `json:"records"`
+	Total   int                `json:"total"`
+}
+
+// searchScanBatch 启用访问控制时按批加载命中的文章并过滤可见性
+const searchScanBatch = 200
+
+// Search 按标题与正文全文检索文章
+// 启用访问控制时先按可见性过滤全部命中再分页，Total 只统计当前主体可见的文章。
+func (s *Service) Search(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	if s.searchIndex == nil {
+		return nil, ErrBadRequest.WithMsg("未启用全文检索")
+	}
+	if strings.TrimSpace(query.Text) == "" {
+		return nil, ErrBadRequest.WithMsg("搜索关键词不能为空")
+	}
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+	if s.aclEnabled() {
+		p, ok := PrincipalFromContext(ctx)
+		if !ok {
+			return nil, ErrForbidden.WithMsg("未登录")
+		}
+		if !p.IsAdmin() {
+			return s.searchVisible(ctx, query)
+		}
+	}
+
+	hits, total, err := s.searchIndex.Search(ctx, query)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "全文检索失败", zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	records, err := s.searchResultItems(ctx, hits)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Total: total, Records: records}, nil
+}
+
+// searchVisible 取出全部命中，按批过滤为当前主体可见的文章后再分页
+// 索引不含授权信息，先分页再过滤会得到残缺的页面，且 Total 会暴露不可见文章的命中数。
+func (s *Service) searchVisible(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	all := *query
+	all.Offset, all.Limit = 0, 0
+	hits, _, err := s.searchIndex.Search(ctx, &all)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "全文检索失败", zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	result := &SearchResult{Records: make([]SearchResultItem, 0, query.Limit)}
+	for start := 0; start < len(hits); start += searchScanBatch {
+		items, err := s.searchResultItems(ctx, hits[start:min(start+searchScanBatch, len(hits))])
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if result.Total >= query.Offset && len(result.Records) < query.Limit {
+				result.Records = append(result.Records, item)
+			}
+			result.Total++
+		}
+	}
+	return result, nil
+}
+
+// searchResultItems 加载命中的文章，按命中顺序返回当前主体可见且未删除的结果
+func (s *Service) searchResultItems(ctx context.Context, hits []SearchHit) ([]SearchResultItem, error) {
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ArticleID
+	}
+	articles, err := s.articleRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if articles, err = s.filterVisible(ctx, articles); err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.Article, len(articles))
+	for i := range articles {
+		byID[articles[i].ID] = &articles[i]
+	}
+
+	items := make([]SearchResultItem, 0, len(hits))
+	for _, hit := range hits {
+		article, ok := byID[hit.ArticleID]
+		if !ok || article.IsDeleted() {
+			// 索引滞后于数据库，跳过已不存在的文章
+			continue
+		}
+		items = append(items, SearchResultItem{
+			Article:        article,
+			Score:          hit.Score,
+			TitleHighlight: hit.TitleHighlight,
+			Snippet:        hit.Snippet,
+		})
+	}
+	return items, nil
+}
+
+// ReindexArticle 重建单篇文章的索引（用于索引重建或修复）
+func (s *Service) ReindexArticle(ctx context.Context, articleID uint) error {
+	if s.searchIndex == nil {
+		return ErrBadRequest.WithMsg("未启用全文检索")
+	}
+
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return s.reindex(ctx, article)
+}
+
+// RebuildSearchIndex 为全部未删除的文章重建索引，返回成功索引的数量
+// 内置索引只保存在内存中，服务启动后需调用一次；不做权限校验，供启动流程或管理任务调用。
+// 单篇失败时记录日志并继续，最后返回遇到的第一个错误。
+func (s *Service) RebuildSearchIndex(ctx context.Context) (int, error) {
+	if s.searchIndex == nil {
+		return 0, ErrBadRequest.WithMsg("未启用全文检索")
+	}
+
+	var indexed int
+	var firstErr error
+	var cursor *ArticleCursor
+	for {
+		articles, err := s.articleRepo.ListByCursor(ctx, &ArticleQuery{}, cursor, searchScanBatch)
+		if err != nil {
+			s.logger.ErrorCtx(ctx, "查询待索引文章失败", zap.Error(err))
+			return indexed, ErrDatabaseError.Wrap(err)
+		}
+		for i := range articles {
+			if err := s.reindex(ctx, &articles[i]); err != nil {
+				s.logger.ErrorCtx(ctx, "重建文章索引失败", zap.Uint("article_id", articles[i].ID), zap.Error(err))
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			indexed++
+		}
+		if len(articles) < searchScanBatch {
+			break
+		}
+		last := articles[len(articles)-1]
+		cursor = &ArticleCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	s.logger.InfoCtx(ctx, "全文索引重建完成", zap.Int("count", indexed))
+	return indexed, firstErr
+}
+
+func (s *Service) reindex(ctx context.Context, article *model.Article) error {
+	body, err := s.searchBody(ctx, article)
+	if err != nil {
+		return err
+	}
+
+	doc := &SearchDocument{
+		ArticleID:   article.ID,
+		Title:       article.Title,
+		Body:        body,
+		ArticleType: article.ArticleType,
+		OwnerID:     article.OwnerID,
+		OwnerType:   article.OwnerType,
+		FolderID:    article.FolderID,
+	}
+	if err := s.searchIndex.Index(ctx, doc); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+// indexArticle 写操作提交后更新索引；索引失败只记录日志，不影响业务结果
+func (s *Service) indexArticle(ctx context.Context, articleID uint) {
+	if s.searchIndex == nil {
+		return
+	}
+	// 写操作已通过权限校验，这里直接读取文章
+	article, err := s.findArticle(ctx, articleID)
+	if err == nil {
+		err = s.reindex(ctx, article)
+	}
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "更新文章索引失败", zap.Uint("article_id", articleID), zap.Error(err))
+	}
+}
+
+// unindexArticle 从索引中移除文章
+func (s *Service) unindexArticle(ctx context.Context, articleID uint) {
+	if s.searchIndex == nil {
+		return
+	}
+	if err := s.searchIndex.Remove(ctx, articleID); err != nil {
+		s.logger.ErrorCtx(ctx, "移除文章索引失败", zap.Uint("article_id", articleID), zap.Error(err))
+	}
+}
+
+// searchBody 提取文章正文的纯文本
+func (s *Service) searchBody(ctx context.Context, article *model.Article) (string, error) {
+	switch article.ArticleType {
+	case model.ArticleTypeMarkdown:
+		markdown, err := s.markdownRepo.FindByArticleID(ctx, article.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", nil
+			}
+			return "", ErrDatabaseError.Wrap(err)
+		}
+		return markdown.Content, nil
+
+	case model.ArticleTypeRichText:
+		richText, err := s.richTextRepo.FindByArticleID(ctx, article.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", nil
+			}
+			return "", ErrDatabaseError.Wrap(err)
+		}
+		return htmlToText(richText.Content), nil
+
+	case model.ArticleTypeTable:
+		rows, err := s.tableRowRepo.FindByArticleID(ctx, article.ID)
+		if err != nil {
+			return "", ErrDatabaseError.Wrap(err)
+		}
+		var b strings.Builder
+		for _, row := range rows {
+			keys := make([]string, 0, len(row.RowData))
+			for k := range row.RowData {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if v := row.RowData[k]; v != nil {
+					fmt.Fprintf(&b, "%v ", v)
+				}
+			}
+			b.WriteString("\n")
+		}
+		return b.String(), nil
+	}
+	return "", nil
+}
+
+// htmlToText 提取 HTML 中的文本内容
+func htmlToText(content string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			// 块级标签之间补空格，避免相邻段落的词粘连
+			b.WriteByte(' ')
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package article
+
+import (
+	"context"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InvertedIndex 内存倒排索引（纯 Go 实现，进程重启后需重建）
+// 拉丁文按单词切分并转小写；中日韩文字同时索引单字与二元组（bigram），
+// 查询时连续的中文按二元组匹配，近似短语搜索。排序使用 BM25，标题命中加权。
+type InvertedIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint]*indexedDoc
+	postings map[string]map[uint]struct{}
+	totalLen int
+}
+
+type indexedDoc struct {
+	doc        SearchDocument
+	titleTerms map[string]int
+	bodyTerms  map[string]int
+	length     int
+}
+
+// BM25 参数与标题权重
+const (
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	titleBoost  = 3.0
+	snippetSize = 80 // 摘要长度（字符）
+)
+
+func NewInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		docs:     make(map[uint]*indexedDoc),
+		postings: make(map[string]map[uint]struct{}),
+	}
+}
+
+func (idx *InvertedIndex) Index(ctx context.Context, doc *SearchDocument) error {
+	d := &indexedDoc{
+		doc:        *doc,
+		titleTerms: termFrequencies(tokenize(doc.Title, true)),
+		bodyTerms:  termFrequencies(tokenize(doc.Body, true)),
+	}
+	for _, n := range d.titleTerms {
+		d.length += n
+	}
+	for _, n := range d.bodyTerms {
+		d.length += n
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(doc.ArticleID)
+	idx.docs[doc.ArticleID] = d
+	idx.totalLen += d.length
+	for term := range d.titleTerms {
+		idx.addPosting(term, doc.ArticleID)
+	}
+	for term := range d.bodyTerms {
+		idx.addPosting(term, doc.ArticleID)
+	}
+	return nil
+}
+
+func (idx *InvertedIndex) Remove(ctx context.Context, articleID uint) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(articleID)
+	return nil
+}
+
+func (idx *InvertedIndex) Search(ctx context.Context, query *SearchQuery) ([]SearchHit, int, error) {
+	terms := uniqueTerms(tokenize(query.Text, false))
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// 从文档最少的词开始求交集（所有词都需命中）
+	sort.Slice(terms, func(i, j int) bool {
+		return len(idx.postings[terms[i]]) < len(idx.postings[terms[j]])
+	})
+	var candidates []uint
+	for id := range idx.postings[terms[0]] {
+		candidates = append(candidates, id)
+	}
+
+	n := float64(len(idx.docs))
+	avgLen := 1.0
+	if len(idx.docs) > 0 && idx.totalLen > 0 {
+		avgLen = float64(idx.totalLen) / n
+	}
+
+	var hits []SearchHit
+	for _, id := range candidates {
+		d := idx.docs[id]
+		if !query.matches(&d.doc) {
+			continue
+		}
+		score, all := 0.0, true
+		for _, term := range terms {
+			if _, ok := idx.postings[term][id]; !ok {
+				all = false
+				break
+			}
+			df := float64(len(idx.postings[term]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			tf := float64(d.bodyTerms[term]) + titleBoost*float64(d.titleTerms[term])
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(d.length)/avgLen)
+			score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if !all {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			ArticleID:      id,
+			Score:          score,
+			TitleHighlight: highlight(d.doc.Title, terms, 0),
+			Snippet:        highlight(d.doc.Body, terms, snippetSize),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ArticleID > hits[j].ArticleID
+	})
+
+	total := len(hits)
+	start := min(max(query.Offset, 0), total)
+	end := total
+	if query.Limit > 0 {
+		end = min(start+query.Limit, total)
+	}
+	return hits[start:end], total, nil
+}
+
+func (idx *InvertedIndex) addPosting(term string, id uint) {
+	ids, ok := idx.postings[term]
+	if !ok {
+		ids = make(map[uint]struct{})
+		idx.postings[term] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (idx *InvertedIndex) removeLocked(id uint) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, terms := range []map[string]int{d.titleTerms, d.bodyTerms} {
+		for term := range terms {
+			if ids := idx.postings[term]; ids != nil {
+				delete(ids, id)
+				if len(ids) == 0 {
+					delete(idx.postings, term)
+				}
+			}
+		}
+	}
+	idx.totalLen -= d.length
+	delete(idx.docs, id)
+}
+
+// ==================== 分词 ====================
+
+// isCJK 是否为按字切分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// tokenize 切分文本；forIndex 为 true 时中文额外输出单字，便于单字查询
+func tokenize(text string, forIndex bool) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+			if forIndex {
+				for _, r := range cjk {
+					tokens = append(tokens, string(r))
+				}
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func termFrequencies(tokens []string) map[string]int {
+	tf := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		tf[t]++
+	}
+	return tf
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	var terms []string
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// ==================== 高亮 ====================
+
+// highlight 用 <mark> 标出命中词并转义其余内容；size > 0 时截取首个命中附近的片段
+func highlight(text string, terms []string, size int) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// 少数字符转小写后字节长度变化，偏移无法对齐时退化为区分大小写匹配
+		lower = text
+	}
+
+	// 收集命中区间（字节偏移）
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		for from := 0; ; {
+			i := strings.Index(lower[from:], term)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			from = start + len(term)
+			if !isCJKTerm(term) && !atWordBoundary(lower, start, from) {
+				// 拉丁词按整词索引，高亮时同样只匹配整词
+				continue
+			}
+			spans = append(spans, span{start, from})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	// 合并重叠区间（中文二元组会互相重叠）
+	var merged []span
+	for _, sp := range spans {
+		if n := len(merged); n > 0 && sp.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, sp.end)
+			continue
+		}
+		merged = append(merged, sp)
+	}
+
+	// 截取片段
+	from, to := 0, len(text)
+	if size > 0 && utf8.RuneCountInString(text) > size {
+		center := 0
+		if len(merged) > 0 {
+			center = merged[0].start
+		}
+		from = backRunes(text, center, size/4)
+		to = forwardRunes(text, from, size)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range merged {
+		if sp.end <= from || sp.start >= to {
+			continue
+		}
+		start, end := max(sp.start, from), min(sp.end, to)
+		b.WriteString(html.EscapeString(text[pos:start]))
+		b.WriteString("<mark>" + html.EscapeString(text[start:end]) + "</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func isCJKTerm(term string) bool {
+	r, _ := utf8.DecodeRuneInString(term)
+	return isCJK(r)
+}
+
+// atWordBoundary [start, end) 两侧是否不与字母数字相连
+func atWordBoundary(s string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	if end < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[end:])
+		if !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// backRunes 从字节偏移 pos 向前回退 n 个字符
+func backRunes(s string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// forwardRunes 从字节偏移 pos 向后前进 n 个字符
+func forwardRunes(s string, pos, n int) int {
+	for ; n > 0 && pos < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return pos
+}
@@ -14,13 +14,30 @@ import (
 
 // Service 文章服务
 type Service struct {
-	articleRepo  ArticleRepository
-	markdownRepo MarkdownArticleRepository
-	richTextRepo RichTextArticleRepository
-	tableRepo    TableArticleRepository
-	tableRowRepo TableArticleRowRepository
-	logger       *logger.CtxZapLogger
-	dispatcher   event.Dispatcher // 事件分发器（可选）
+	articleRepo      ArticleRepository
+	markdownRepo     MarkdownArticleRepository
+	richTextRepo     RichTextArticleRepository
+	tableRepo        TableArticleRepository
+	tableRowRepo     TableArticleRowRepository
+	revisionRepo     ArticleRevisionRepository              // 内容修订记录（可选）
+	historyRepo      TableArticleStructureHistoryRepository // 表格结构变更历史（可选）
+	txManager        TxManager                              // 事务管理器（可选）
+	markdownRenderer MarkdownRenderer                       // Markdown 渲染器（默认 goldmark GFM）
+	sanitizePolicy   *SanitizePolicy                        // 富文本清洗策略（默认 standard）
+	searchIndex      SearchIndex                            // 全文检索索引（可选）
+	grantRepo        ArticleGrantRepository                 // 访问授权（可选，注入后启用访问控制）
+	folderResolver   FolderAncestorResolver                 // 文件夹层级解析（可选，用于继承上级授权）
+	shareLinkRepo    ArticleShareLinkRepository             // 分享链接（可选）
+	tagRepo          TagRepository                          // 标签（可选）
+	commentRepo      ArticleCommentRepository               // 评论（可选）
+	attachmentRepo   ArticleAttachmentRepository            // 附件（可选，与 blobStore 一起注入）
+	blobStore        BlobStore                              // 附件内容存储
+	attachmentLimits AttachmentLimits                       // 附件大小与类型限制
+	markdownOpRepo   MarkdownOperationRepository            // Markdown 协同编辑操作日志（可选）
+	collabOptions    CollabOptions                          // 协同编辑参数
+	editLockRepo     ArticleEditLockRepository              // 编辑锁（可选）
+	logger           *logger.CtxZapLogger
+	dispatcher       event.Dispatcher // 事件分发器（可选）
 }
 
 // ServiceOption 服务配置选项
@@ -33,6 +50,48 @@ func WithDispatcher(d event.Dispatcher) ServiceOption {
 	}
 }
 
+// WithTxManager 注入事务管理器，多表写操作将在同一事务内提交
+func WithTxManager(m TxManager) ServiceOption {
+	return func(s *Service) {
+		s.txManager = m
+	}
+}
+
+// WithMarkdownRenderer 替换默认的 Markdown 渲染器
+func WithMarkdownRenderer(r MarkdownRenderer) ServiceOption {
+	return func(s *Service) {
+		s.markdownRenderer = r
+	}
+}
+
+// WithSanitizePolicy 设置富文本 HTML 清洗策略（StrictPolicy / StandardPolicy / PermissivePolicy 或自定义）
+func WithSanitizePolicy(p *SanitizePolicy) ServiceOption {
+	return func(s *Service) {
+		s.sanitizePolicy = p
+	}
+}
+
+// WithSearchIndex 注入全文检索索引，文章写操作后自动更新索引
+func WithSearchIndex(idx SearchIndex) ServiceOption {
+	return func(s *Service) {
+		s.searchIndex = idx
+	}
+}
+
+// WithRevisionRepository 注入内容修订记录仓储，启用 Markdown / 富文本修订历史
+func WithRevisionRepository(r ArticleRevisionRepository) ServiceOption {
+	return func(s *Service) {
+		s.revisionRepo = r
+	}
+}
+
+// WithStructureHistoryRepository 注入表格结构变更历史仓储，启用结构版本与回滚
+func WithStructureHistoryRepository(r TableArticleStructureHistoryRepository) ServiceOption {
+	return func(s *Service) {
+		s.historyRepo = r
+	}
+}
+
 // NewService 创建文章服务
 func NewService(
 	articleRepo ArticleRepository,
@@ -44,12 +103,16 @@ func NewService(
 	opts ...ServiceOption,
 ) *Service {
 	s := &Service{
-		articleRepo:  articleRepo,
-		markdownRepo: markdownRepo,
-		richTextRepo: richTextRepo,
-		tableRepo:    tableRepo,
-		tableRowRepo: tableRowRepo,
-		logger:       log,
+		articleRepo:      articleRepo,
+		markdownRepo:     markdownRepo,
+		richTextRepo:     richTextRepo,
+		tableRepo:        tableRepo,
+		tableRowRepo:     tableRowRepo,
+		markdownRenderer: NewGoldmarkRenderer(),
+		sanitizePolicy:   StandardPolicy(),
+		attachmentLimits: DefaultAttachmentLimits(),
+		collabOptions:    DefaultCollabOptions(),
+		logger:           log,
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -68,12 +131,40 @@ type CreateArticleInput struct {
 	OwnerType   string
 }
 
-// CreateArticle 创建文章
+// CreateArticle 创建文章（指定文件夹时需要对该文件夹有 editor 权限）
 func (s *Service) CreateArticle(ctx context.Context, input *CreateArticleInput) (*model.Article, error) {
+	var article *model.Article
+	err := s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		var err error
+		article, err = s.createArticle(ctx, repos, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 发布文章创建事件
+	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
+	s.indexArticle(ctx, article.ID)
+
+	return article, nil
+}
+
+// createArticle 写入文章主表（不分发事件，由调用方在事务提交后分发）
+// 指定文件夹时需要对该文件夹有 editor 权限，否则新文章会继承无权访问的文件夹授权。
+func (s *Service) createArticle(ctx context.Context, repos *Repositories, input *CreateArticleInput) (*model.Article, error) {
 	// 验证文章类型
 	if !isValidArticleType(input.ArticleType) {
 		return nil, ErrBadRequest.WithMsgf("不支持的文章类型: %s", input.ArticleType)
 	}
+	if err := s.authorizeOwnerScope(ctx, input.OwnerType, input.OwnerID); err != nil {
+		return nil, err
+	}
+	if input.FolderID != nil {
+		if err := s.authorizeFolder(ctx, *input.FolderID, model.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
 
 	article := &model.Article{
 		Title:       input.Title,
@@ -86,21 +177,22 @@ func (s *Service) CreateArticle(ctx context.Context, input *CreateArticleInput)
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := s.articleRepo.Create(ctx, article); err != nil {
+	if err := repos.Article.Create(ctx, article); err != nil {
 		s.logger.ErrorCtx(ctx, "创建文章失败", zap.Error(err))
 		return nil, ErrDatabaseError.Wrap(err)
 	}
 
 	s.logger.InfoCtx(ctx, "文章创建成功", zap.Uint("article_id", article.ID))
-
-	// 发布文章创建事件
-	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
-
 	return article, nil
 }
 
 // GetArticle 获取文章详情
 func (s *Service) GetArticle(ctx context.Context, id uint) (*model.Article, error) {
+	return s.getArticleFor(ctx, id, model.RoleViewer)
+}
+
+// findArticle 获取未删除的文章（不做权限校验）
+func (s *Service) findArticle(ctx context.Context, id uint) (*model.Article, error) {
 	article, err := s.articleRepo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -118,47 +210,96 @@ func (s *Service) GetArticle(ctx context.Context, id uint) (*model.Article, erro
 
 // UpdateArticleInput 更新文章输入
 type UpdateArticleInput struct {
-	Title    *string
-	Status   *int
-	FolderID **uint // 二级指针：nil=不更新, *nil=清空, *value=设置新值
+	Title       *string
+	Status      *int
+	FolderID    **uint      // 二级指针：nil=不更新, *nil=清空, *value=设置新值
+	PublishAt   **time.Time // 定时发布时间，同 FolderID；手动发布时清空
+	UnpublishAt **time.Time // 定时下线时间，同 FolderID；须晚于 PublishAt
 }
 
-// UpdateArticle 更新文章
+// UpdateArticle 更新文章（需要 editor；修改文件夹时同 MoveToFolder，需要 owner 及目标文件夹 editor）
 func (s *Service) UpdateArticle(ctx context.Context, id uint, input *UpdateArticleInput) error {
-	article, err := s.GetArticle(ctx, id)
+	article, err := s.getArticleFor(ctx, id, model.RoleEditor)
 	if err != nil {
 		return err
 	}
 
+	fromStatus := article.Status
+	wasPublished := article.IsPublished()
+	var columns []string // 只写入本次修改的列，避免覆盖定时任务或删除在此期间写入的字段
 	if input.Title != nil {
 		article.Title = *input.Title
+		columns = append(columns, "title")
 	}
 	if input.Status != nil {
+		// 删除须通过 DeleteArticle（需要 owner，并记录回收站信息）
+		if *input.Status != model.StatusDraft && *input.Status != model.StatusPublished {
+			return ErrBadRequest.WithMsgf("无效的文章状态: %d", *input.Status)
+		}
 		article.Status = *input.Status
+		columns = append(columns, "status")
 	}
 	if input.FolderID != nil {
+		if err := s.authorizeMove(ctx, article, *input.FolderID); err != nil {
+			return err
+		}
 		article.FolderID = *input.FolderID
+		columns = append(columns, "folder_id")
+	}
+	if input.PublishAt != nil {
+		article.PublishAt = *input.PublishAt
+		columns = append(columns, "publish_at")
+	}
+	if input.UnpublishAt != nil {
+		article.UnpublishAt = *input.UnpublishAt
+		columns = append(columns, "unpublish_at")
+	}
+	// 手动发布后不再需要定时发布
+	if article.IsPublished() && !wasPublished && article.PublishAt != nil {
+		article.PublishAt = nil
+		if input.PublishAt == nil {
+			columns = append(columns, "publish_at")
+		}
+	}
+	if article.PublishAt != nil && article.UnpublishAt != nil && !article.UnpublishAt.After(*article.PublishAt) {
+		return ErrBadRequest.WithMsg("定时下线时间必须晚于定时发布时间")
 	}
 	article.UpdatedAt = time.Now()
 
-	if err := s.articleRepo.Update(ctx, article); err != nil {
+	// 以读取时的状态为条件写入：期间被定时任务切换状态或移入回收站时返回 ErrConflict
+	if err := s.articleRepo.Update(ctx, article, fromStatus, columns...); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return ErrConflict.WithMsg("文章状态已变化，请刷新后重试")
+		}
 		s.logger.ErrorCtx(ctx, "更新文章失败", zap.Uint("article_id", id), zap.Error(err))
 		return ErrDatabaseError.Wrap(err)
 	}
 
 	s.logger.InfoCtx(ctx, "文章更新成功", zap.Uint("article_id", id))
+	if article.IsPublished() != wasPublished {
+		s.dispatchAsync(ctx, NewArticlePublicationEvent(id, article.FolderID, article.IsPublished(), false))
+	}
+	s.indexArticle(ctx, id)
 	return nil
 }
 
-// DeleteArticle 删除文章（软删除）
+// DeleteArticle 删除文章（移入回收站，可通过 RestoreArticle 恢复；内容与附件在 PurgeArticle 时才删除）
 func (s *Service) DeleteArticle(ctx context.Context, id uint) error {
-	article, err := s.GetArticle(ctx, id)
+	article, err := s.getArticleFor(ctx, id, model.RoleOwner)
 	if err != nil {
 		return err
 	}
 	folderID := article.FolderID // 保存删除前的 folderID
 
-	if err := s.articleRepo.Delete(ctx, id); err != nil {
+	now := time.Now()
+	prevStatus := article.Status
+	article.PrevStatus = &prevStatus
+	article.DeletedAt = &now
+	article.UpdatedAt = now
+	if err := s.articleRepo.Delete(ctx, article); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return ErrDeleted.WithMsg("文章已删除")
+		}
 		s.logger.ErrorCtx(ctx, "删除文章失败", zap.Uint("article_id", id), zap.Error(err))
 		return ErrDatabaseError.Wrap(err)
 	}
@@ -167,6 +308,7 @@ func (s *Service) DeleteArticle(ctx context.Context, id uint) error {
 
 	// 发布文章删除事件
 	s.dispatchAsync(ctx, NewArticleDeletedEvent(id, folderID))
+	s.unindexArticle(ctx, id)
 
 	return nil
 }
@@ -184,40 +326,22 @@ type PageResult struct {
 	IsLast      bool            `json:"isLast"`
 }
 
-// ListArticles 分页查询文章
-func (s *Service) ListArticles(ctx context.Context, page, size int, ownerId *uint, ownerType, articleType, title string, folderID *uint) (*PageResult, error) {
-	articles, total, err := s.articleRepo.Paginate(ctx, page, size, ownerId, ownerType, articleType, title, folderID)
-	if err != nil {
-		s.logger.ErrorCtx(ctx, "查询文章列表失败", zap.Error(err))
-		return nil, ErrDatabaseError.Wrap(err)
+// ListArticles 按查询条件分页查询文章
+func (s *Service) ListArticles(ctx context.Context, q *ArticleQuery) (*PageResult, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
 	}
-
-	pages := int(total) / size
-	if int(total)%size > 0 {
-		pages++
+	if err := s.restrictVisible(ctx, q); err != nil {
+		return nil, err
 	}
 
-	return &PageResult{
-		Records:     articles,
-		Total:       total,
-		Size:        size,
-		Current:     page,
-		Pages:       pages,
-		HasPrevious: page > 1,
-		HasNext:     page < pages,
-		IsFirst:     page == 1,
-		IsLast:      page >= pages,
-	}, nil
-}
-
-// ListArticlesByFolderIDs 分页查询文章（支持多个文件夹ID，用于树形筛选）
-func (s *Service) ListArticlesByFolderIDs(ctx context.Context, page, size int, ownerId *uint, ownerType, articleType, title string, folderIDs []uint) (*PageResult, error) {
-	articles, total, err := s.articleRepo.PaginateByFolderIDs(ctx, page, size, ownerId, ownerType, articleType, title, folderIDs)
+	articles, total, err := s.articleRepo.Find(ctx, q)
 	if err != nil {
 		s.logger.ErrorCtx(ctx, "查询文章列表失败", zap.Error(err))
 		return nil, ErrDatabaseError.Wrap(err)
 	}
 
+	page, size := q.Page, q.PageSize
 	pages := int(total) / size
 	if int(total)%size > 0 {
 		pages++
@@ -248,33 +372,49 @@ type CreateRichTextArticleInput struct {
 }
 
 // CreateRichTextArticle 创建富文本文章
+// 内容按清洗策略过滤后保存，需要提示用户时可先调用 SanitizeRichText 预览。
 func (s *Service) CreateRichTextArticle(ctx context.Context, input *CreateRichTextArticleInput) (*model.Article, error) {
-	// 1. 创建主表
-	article, err := s.CreateArticle(ctx, &CreateArticleInput{
-		Title:       input.Title,
-		ArticleType: model.ArticleTypeRichText,
-		FolderID:    input.FolderID,
-		OwnerID:     input.OwnerID,
-		OwnerType:   input.OwnerType,
+	content := s.sanitizeRichText(ctx, input.Content)
+
+	var article *model.Article
+	err := s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		// 1. 创建主表
+		var err error
+		article, err = s.createArticle(ctx, repos, &CreateArticleInput{
+			Title:       input.Title,
+			ArticleType: model.ArticleTypeRichText,
+			FolderID:    input.FolderID,
+			OwnerID:     input.OwnerID,
+			OwnerType:   input.OwnerType,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 2. 创建富文本内容
+		richText := &model.RichTextArticle{
+			ArticleID:     article.ID,
+			Content:       content,
+			FormatVersion: "1.0",
+			Version:       1,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+
+		if err := repos.RichText.Create(ctx, richText); err != nil {
+			s.logger.ErrorCtx(ctx, "创建富文本内容失败", zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		// 3. 记录初始修订
+		return s.recordRevision(ctx, repos, article.ID, model.ArticleTypeRichText, content, model.RevisionChangeCreate, nil)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. 创建富文本内容
-	richText := &model.RichTextArticle{
-		ArticleID:     article.ID,
-		Content:       input.Content,
-		FormatVersion: "1.0",
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
-
-	if err := s.richTextRepo.Create(ctx, richText); err != nil {
-		s.logger.ErrorCtx(ctx, "创建富文本内容失败", zap.Error(err))
-		return nil, ErrDatabaseError.Wrap(err)
-	}
-
+	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
+	s.indexArticle(ctx, article.ID)
 	return article, nil
 }
 
@@ -282,6 +422,7 @@ func (s *Service) CreateRichTextArticle(ctx context.Context, input *CreateRichTe
 type RichTextArticleContent struct {
 	Article *model.Article `json:"article"`
 	Content string         `json:"content"`
+	Version int            `json:"version"` // 内容版本，更新时作为 expectedVersion 提交；尚无内容时为 0
 }
 
 // GetRichTextArticleContent 获取富文本文章内容
@@ -306,39 +447,95 @@ func (s *Service) GetRichTextArticleContent(ctx context.Context, articleID uint)
 	return &RichTextArticleContent{
 		Article: article,
 		Content: richText.Content,
+		Version: richText.Version,
 	}, nil
 }
 
-// UpdateRichTextContent 更新富文本内容
-func (s *Service) UpdateRichTextContent(ctx context.Context, articleID uint, content string) error {
-	article, err := s.GetArticle(ctx, articleID)
+// UpdateRichTextContent 更新富文本内容，返回清洗报告（供编辑器提示被移除的内容）与保存后的版本
+// expectedVersion 为编辑开始时读取的版本，与当前版本不一致时返回 ErrConflict；<= 0 时不校验。
+func (s *Service) UpdateRichTextContent(ctx context.Context, articleID uint, content string, expectedVersion int) (*SanitizeReport, int, error) {
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	if article.ArticleType != model.ArticleTypeRichText {
-		return ErrBadRequest.WithMsg("该文章不是富文本类型")
+		return nil, 0, ErrBadRequest.WithMsg("该文章不是富文本类型")
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return nil, 0, err
 	}
 
-	richText, err := s.richTextRepo.FindByArticleID(ctx, articleID)
+	content, report := s.sanitizePolicy.Sanitize(content)
+	var version int
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		var err error
+		_, version, err = s.saveRichTextContent(ctx, repos, articleID, content, expectedVersion, model.RevisionChangeUpdate, nil)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 不存在则创建
-			richText = &model.RichTextArticle{
-				ArticleID:     articleID,
-				Content:       content,
-				FormatVersion: "1.0",
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-			}
-			return s.richTextRepo.Create(ctx, richText)
+		return nil, 0, err
+	}
+
+	s.indexArticle(ctx, articleID)
+	return report, version, nil
+}
+
+// SanitizeRichText 按当前策略预览清洗结果（不写入）
+func (s *Service) SanitizeRichText(content string) (string, *SanitizeReport) {
+	return s.sanitizePolicy.Sanitize(content)
+}
+
+// sanitizeRichText 清洗富文本，有内容被移除时记录日志
+func (s *Service) sanitizeRichText(ctx context.Context, content string) string {
+	cleaned, report := s.sanitizePolicy.Sanitize(content)
+	if report.Modified() {
+		s.logger.InfoCtx(ctx, "富文本内容已按策略清洗", zap.String("policy", report.Policy), zap.Int("removed_kinds", len(report.Removed)))
+	}
+	return cleaned
+}
+
+// saveRichTextContent 写入富文本内容并记录修订，返回内容是否发生变化及当前版本
+// content 必须是已清洗的 HTML；expectedVersion <= 0 时不校验版本。
+func (s *Service) saveRichTextContent(ctx context.Context, repos *Repositories, articleID uint, content string, expectedVersion int, changeType string, restoredFrom *int) (bool, int, error) {
+	richText, err := repos.RichText.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, 0, ErrDatabaseError.Wrap(err)
 		}
-		return ErrDatabaseError.Wrap(err)
+		if err := checkVersion(0, expectedVersion); err != nil {
+			return false, 0, err
+		}
+		// 不存在则创建
+		richText = &model.RichTextArticle{
+			ArticleID:     articleID,
+			Content:       content,
+			FormatVersion: "1.0",
+			Version:       1,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if err := repos.RichText.Create(ctx, richText); err != nil {
+			return false, 0, ErrDatabaseError.Wrap(err)
+		}
+		return true, richText.Version, s.recordRevision(ctx, repos, articleID, model.ArticleTypeRichText, content, changeType, restoredFrom)
+	}
+
+	if err := checkVersion(richText.Version, expectedVersion); err != nil {
+		return false, 0, err
+	}
+	if richText.Content == content {
+		return false, richText.Version, nil
 	}
 
 	richText.Content = content
+	richText.Version++
 	richText.UpdatedAt = time.Now()
-	return s.richTextRepo.Update(ctx, richText)
+	if err := repos.RichText.Update(ctx, richText, richText.Version-1); err != nil {
+		return false, 0, versionedWriteError(err)
+	}
+
+	return true, richText.Version, s.recordRevision(ctx, repos, articleID, model.ArticleTypeRichText, content, changeType, restoredFrom)
 }
 
 // ==================== 表格文章操作 ====================
@@ -358,66 +555,91 @@ type CreateTableArticleInput struct {
 
 // CreateTableArticle 创建表格文章
 func (s *Service) CreateTableArticle(ctx context.Context, input *CreateTableArticleInput) (*model.Article, error) {
-	// 1. 创建主表
-	article, err := s.CreateArticle(ctx, &CreateArticleInput{
-		Title:       input.Title,
-		ArticleType: model.ArticleTypeTable,
-		FolderID:    input.FolderID,
-		OwnerID:     input.OwnerID,
-		OwnerType:   input.OwnerType,
-	})
+	schema, err := parseStructure(input.Structure)
 	if err != nil {
 		return nil, err
 	}
-
-	// 2. 创建表格结构
-	structure := model.JSONArray(input.Structure)
-	var columnOrder model.JSONArray
-	if len(input.ColumnOrder) > 0 {
-		for _, col := range input.ColumnOrder {
-			columnOrder = append(columnOrder, map[string]interface{}{"field": col})
-		}
-	}
-	var filters model.JSONArray
-	if len(input.Filters) > 0 {
-		filters = model.JSONArray(input.Filters)
+	if err := prepareTableRows(schema, input.Data); err != nil {
+		return nil, err
 	}
-
-	tableArticle := &model.TableArticle{
-		ArticleID:   article.ID,
-		TableID:     input.TableID,
-		Structure:   structure,
-		ColumnOrder: columnOrder,
-		Filters:     filters,
-		Version:     1,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	if _, err := parseSavedFilters(model.JSONArray(input.Filters)); err != nil {
+		return nil, ErrBadRequest.WithMsgf("过滤条件无效: %v", err)
 	}
 
-	if err := s.tableRepo.Create(ctx, tableArticle); err != nil {
-		s.logger.ErrorCtx(ctx, "创建表格结构失败", zap.Error(err))
-		return nil, ErrDatabaseError.Wrap(err)
-	}
+	var article *model.Article
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		// 1. 创建主表
+		var err error
+		article, err = s.createArticle(ctx, repos, &CreateArticleInput{
+			Title:       input.Title,
+			ArticleType: model.ArticleTypeTable,
+			FolderID:    input.FolderID,
+			OwnerID:     input.OwnerID,
+			OwnerType:   input.OwnerType,
+		})
+		if err != nil {
+			return err
+		}
 
-	// 3. 创建行数据
-	if len(input.Data) > 0 {
-		rows := make([]model.TableArticleRow, len(input.Data))
-		for i, rowData := range input.Data {
-			idx := i
-			rows[i] = model.TableArticleRow{
-				ArticleID: article.ID,
-				RowData:   model.JSONMap(rowData),
-				RowIndex:  &idx,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
+		// 2. 创建表格结构
+		structure := model.JSONArray(input.Structure)
+		var columnOrder model.JSONArray
+		if len(input.ColumnOrder) > 0 {
+			for _, col := range input.ColumnOrder {
+				columnOrder = append(columnOrder, map[string]interface{}{"field": col})
 			}
 		}
-		if err := s.tableRowRepo.BatchCreate(ctx, rows); err != nil {
-			s.logger.ErrorCtx(ctx, "创建表格行数据失败", zap.Error(err))
-			return nil, ErrDatabaseError.Wrap(err)
+		var filters model.JSONArray
+		if len(input.Filters) > 0 {
+			filters = model.JSONArray(input.Filters)
 		}
+
+		tableArticle := &model.TableArticle{
+			ArticleID:   article.ID,
+			TableID:     input.TableID,
+			Structure:   structure,
+			ColumnOrder: columnOrder,
+			Filters:     filters,
+			Version:     1,
+			DataVersion: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := repos.Table.Create(ctx, tableArticle); err != nil {
+			s.logger.ErrorCtx(ctx, "创建表格结构失败", zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+		if err := s.recordStructureHistory(ctx, repos, tableArticle, model.StructureChangeCreate, "创建表格"); err != nil {
+			return err
+		}
+
+		// 3. 创建行数据
+		if len(input.Data) > 0 {
+			rows := make([]model.TableArticleRow, len(input.Data))
+			for i, rowData := range input.Data {
+				idx := i
+				rows[i] = model.TableArticleRow{
+					ArticleID: article.ID,
+					RowData:   model.JSONMap(rowData),
+					RowIndex:  &idx,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}
+			}
+			if err := repos.TableRow.BatchCreate(ctx, rows); err != nil {
+				s.logger.ErrorCtx(ctx, "创建表格行数据失败", zap.Error(err))
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
+	s.indexArticle(ctx, article.ID)
 	return article, nil
 }
 
@@ -428,6 +650,8 @@ type TableArticleContent struct {
 	Data        []map[string]interface{} `json:"data"`
 	ColumnOrder []string                 `json:"columnOrder"`
 	Filters     []map[string]interface{} `json:"filters"`
+	Version     int                      `json:"version"`     // 结构版本，UpdateTableStructure 的 expectedVersion
+	DataVersion int                      `json:"dataVersion"` // 行数据版本，SaveTableRows 的 expectedVersion
 }
 
 // GetTableArticleContent 获取表格文章内容
@@ -454,9 +678,14 @@ func (s *Service) GetTableArticleContent(ctx context.Context, articleID uint) (*
 		return nil, ErrDatabaseError.Wrap(err)
 	}
 
-	// 转换行数据
+	// 转换行数据并计算公式列
+	schema := readSchema(tableArticle)
 	data := make([]map[string]interface{}, len(rows))
 	for i, row := range rows {
+		if row.RowData == nil {
+			row.RowData = model.JSONMap{}
+		}
+		schema.ApplyFormulas(row.RowData)
 		data[i] = map[string]interface{}(row.RowData)
 	}
 
@@ -474,6 +703,8 @@ func (s *Service) GetTableArticleContent(ctx context.Context, articleID uint) (*
 		Data:        data,
 		ColumnOrder: columnOrder,
 		Filters:     []map[string]interface{}(tableArticle.Filters),
+		Version:     tableArticle.Version,
+		DataVersion: tableArticle.DataVersion,
 	}, nil
 }
 
@@ -490,38 +721,84 @@ func (s *Service) GetArticleByTableID(ctx context.Context, tableID string) (*mod
 	return s.GetArticle(ctx, tableArticle.ArticleID)
 }
 
-// UpdateTableStructure 更新表格结构
-func (s *Service) UpdateTableStructure(ctx context.Context, articleID uint, structure []map[string]interface{}) error {
-	article, err := s.GetArticle(ctx, articleID)
+// UpdateTableStructure 更新表格结构，返回新的结构版本
+// expectedVersion 为编辑开始时读取的结构版本，与当前版本不一致时返回 ErrConflict；<= 0 时不校验。
+// 已有行不按新结构重新校验，之后行级修改只校验发生变化的单元格。
+func (s *Service) UpdateTableStructure(ctx context.Context, articleID uint, structure []map[string]interface{}, expectedVersion int) (int, error) {
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if article.ArticleType != model.ArticleTypeTable {
-		return ErrBadRequest.WithMsg("该文章不是表格类型")
+		return 0, ErrBadRequest.WithMsg("该文章不是表格类型")
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return 0, err
+	}
+	if _, err := parseStructure(structure); err != nil {
+		return 0, err
 	}
 
-	tableArticle, err := s.tableRepo.FindByArticleID(ctx, articleID)
+	tableArticle, err := s.findTable(ctx, articleID)
 	if err != nil {
-		return ErrDatabaseError.Wrap(err)
+		return 0, err
+	}
+	if err := checkVersion(tableArticle.Version, expectedVersion); err != nil {
+		return 0, err
 	}
 
-	tableArticle.Structure = model.JSONArray(structure)
+	diff := diffStructure(tableArticle.Structure, model.JSONArray(structure))
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		return s.applyTableStructure(ctx, repos, tableArticle, model.JSONArray(structure), model.StructureChangeUpdate, diff.Summary())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return tableArticle.Version, nil
+}
+
+// applyTableStructure 写入新结构、递增版本并记录结构历史
+// 写入以读取时的版本为条件，期间被他人修改时返回 ErrConflict。
+func (s *Service) applyTableStructure(ctx context.Context, repos *Repositories, tableArticle *model.TableArticle, structure model.JSONArray, changeType, description string) error {
+	// 启用历史前创建的表格没有当前版本记录，先补一条基线，保证可以回滚到修改前
+	if err := s.ensureStructureBaseline(ctx, repos, tableArticle); err != nil {
+		return err
+	}
+
+	tableArticle.Structure = structure
 	tableArticle.Version++
 	tableArticle.UpdatedAt = time.Now()
 
-	return s.tableRepo.Update(ctx, tableArticle)
+	if err := repos.Table.Update(ctx, tableArticle, tableArticle.Version-1); err != nil {
+		s.logger.ErrorCtx(ctx, "更新表格结构失败", zap.Uint("article_id", tableArticle.ArticleID), zap.Error(err))
+		return versionedWriteError(err)
+	}
+
+	return s.recordStructureHistory(ctx, repos, tableArticle, changeType, description)
 }
 
-// SaveTableRows 保存表格行数据
-func (s *Service) SaveTableRows(ctx context.Context, articleID uint, rowsData []map[string]interface{}) error {
-	article, err := s.GetArticle(ctx, articleID)
+// SaveTableRows 整表保存行数据，返回新的行数据版本
+// expectedVersion 为读取时的 DataVersion，与当前版本不一致时返回 ErrConflict；<= 0 时不校验。
+func (s *Service) SaveTableRows(ctx context.Context, articleID uint, rowsData []map[string]interface{}, expectedVersion int) (int, error) {
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if article.ArticleType != model.ArticleTypeTable {
-		return ErrBadRequest.WithMsg("该文章不是表格类型")
+		return 0, ErrBadRequest.WithMsg("该文章不是表格类型")
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return 0, err
+	}
+
+	schema, err := s.tableSchema(ctx, articleID)
+	if err != nil {
+		return 0, err
+	}
+	if err := prepareTableRows(schema, rowsData); err != nil {
+		return 0, err
 	}
 
 	rows := make([]model.TableArticleRow, len(rowsData))
@@ -536,7 +813,23 @@ func (s *Service) SaveTableRows(ctx context.Context, articleID uint, rowsData []
 		}
 	}
 
-	return s.tableRowRepo.ReplaceAll(ctx, articleID, rows)
+	var version int
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		var err error
+		if version, err = bumpTableDataVersion(ctx, repos, articleID, expectedVersion); err != nil {
+			return err
+		}
+		if err := repos.TableRow.ReplaceAll(ctx, articleID, rows); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.indexArticle(ctx, articleID)
+	return version, nil
 }
 
 // ==================== Markdown文章操作 ====================
@@ -552,33 +845,46 @@ type CreateMarkdownArticleInput struct {
 
 // CreateMarkdownArticle 创建Markdown文章
 func (s *Service) CreateMarkdownArticle(ctx context.Context, input *CreateMarkdownArticleInput) (*model.Article, error) {
-	// 1. 创建主表
-	article, err := s.CreateArticle(ctx, &CreateArticleInput{
-		Title:       input.Title,
-		ArticleType: model.ArticleTypeMarkdown,
-		FolderID:    input.FolderID,
-		OwnerID:     input.OwnerID,
-		OwnerType:   input.OwnerType,
+	var article *model.Article
+	err := s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		// 1. 创建主表
+		var err error
+		article, err = s.createArticle(ctx, repos, &CreateArticleInput{
+			Title:       input.Title,
+			ArticleType: model.ArticleTypeMarkdown,
+			FolderID:    input.FolderID,
+			OwnerID:     input.OwnerID,
+			OwnerType:   input.OwnerType,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 2. 创建Markdown内容
+		markdown := &model.MarkdownArticle{
+			ArticleID:     article.ID,
+			Content:       input.Content,
+			FormatVersion: "1.0",
+			Version:       1,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		s.renderMarkdown(ctx, markdown)
+
+		if err := repos.Markdown.Create(ctx, markdown); err != nil {
+			s.logger.ErrorCtx(ctx, "创建Markdown内容失败", zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		// 3. 记录初始修订
+		return s.recordRevision(ctx, repos, article.ID, model.ArticleTypeMarkdown, input.Content, model.RevisionChangeCreate, nil)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. 创建Markdown内容
-	markdown := &model.MarkdownArticle{
-		ArticleID:     article.ID,
-		Content:       input.Content,
-		HTMLContent:   "", // 可选：在此处或前端渲染HTML
-		FormatVersion: "1.0",
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
-
-	if err := s.markdownRepo.Create(ctx, markdown); err != nil {
-		s.logger.ErrorCtx(ctx, "创建Markdown内容失败", zap.Error(err))
-		return nil, ErrDatabaseError.Wrap(err)
-	}
-
+	s.dispatchAsync(ctx, NewArticleCreatedEvent(article.ID, article.FolderID))
+	s.indexArticle(ctx, article.ID)
 	return article, nil
 }
 
@@ -586,6 +892,7 @@ func (s *Service) CreateMarkdownArticle(ctx context.Context, input *CreateMarkdo
 type MarkdownArticleContent struct {
 	Article *model.Article `json:"article"`
 	Content string         `json:"content"`
+	Version int            `json:"version"` // 内容版本，更新时作为 expectedVersion 提交；尚无内容时为 0
 }
 
 // GetMarkdownArticleContent 获取Markdown文章内容
@@ -610,63 +917,114 @@ func (s *Service) GetMarkdownArticleContent(ctx context.Context, articleID uint)
 	return &MarkdownArticleContent{
 		Article: article,
 		Content: markdown.Content,
+		Version: markdown.Version,
 	}, nil
 }
 
-// UpdateMarkdownContent 更新Markdown内容
-func (s *Service) UpdateMarkdownContent(ctx context.Context, articleID uint, content string) error {
-	article, err := s.GetArticle(ctx, articleID)
+// UpdateMarkdownContent 更新Markdown内容，返回保存后的版本
+// expectedVersion 为编辑开始时读取的版本，与当前版本不一致时返回 ErrConflict；<= 0 时不校验。
+func (s *Service) UpdateMarkdownContent(ctx context.Context, articleID uint, content string, expectedVersion int) (int, error) {
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if article.ArticleType != model.ArticleTypeMarkdown {
-		return ErrBadRequest.WithMsg("该文章不是Markdown类型")
+		return 0, ErrBadRequest.WithMsg("该文章不是Markdown类型")
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return 0, err
 	}
 
-	markdown, err := s.markdownRepo.FindByArticleID(ctx, articleID)
+	var changed bool
+	var version int
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		var err error
+		changed, version, err = s.saveMarkdownContent(ctx, repos, articleID, content, expectedVersion, model.RevisionChangeUpdate, nil)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 不存在则创建
-			markdown = &model.MarkdownArticle{
-				ArticleID:     articleID,
-				Content:       content,
-				HTMLContent:   "",
-				FormatVersion: "1.0",
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-			}
-			return s.markdownRepo.Create(ctx, markdown)
+		return 0, err
+	}
+
+	// 发布内容更新事件（用于缓存失效）
+	if changed {
+		s.dispatchAsync(ctx, NewArticleContentUpdatedEvent(articleID, "markdown"))
+		s.indexArticle(ctx, articleID)
+	}
+	return version, nil
+}
+
+// saveMarkdownContent 写入Markdown内容并记录修订，返回内容是否发生变化及当前版本
+// expectedVersion <= 0 时不校验版本。
+func (s *Service) saveMarkdownContent(ctx context.Context, repos *Repositories, articleID uint, content string, expectedVersion int, changeType string, restoredFrom *int) (bool, int, error) {
+	markdown, err := repos.Markdown.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, 0, ErrDatabaseError.Wrap(err)
 		}
-		return ErrDatabaseError.Wrap(err)
+		if err := checkVersion(0, expectedVersion); err != nil {
+			return false, 0, err
+		}
+		// 不存在则创建
+		markdown = &model.MarkdownArticle{
+			ArticleID:     articleID,
+			Content:       content,
+			FormatVersion: "1.0",
+			Version:       1,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		s.renderMarkdown(ctx, markdown)
+		if err := repos.Markdown.Create(ctx, markdown); err != nil {
+			return false, 0, ErrDatabaseError.Wrap(err)
+		}
+		return true, markdown.Version, s.recordRevision(ctx, repos, articleID, model.ArticleTypeMarkdown, content, changeType, restoredFrom)
+	}
+
+	if err := checkVersion(markdown.Version, expectedVersion); err != nil {
+		return false, 0, err
+	}
+	if markdown.Content == content {
+		return false, markdown.Version, nil
 	}
 
+	previous := markdown.Content
 	markdown.Content = content
+	markdown.Version++
 	markdown.UpdatedAt = time.Now()
-	if err := s.markdownRepo.Update(ctx, markdown); err != nil {
-		return err
+	s.renderMarkdown(ctx, markdown)
+	if err := repos.Markdown.Update(ctx, markdown, markdown.Version-1); err != nil {
+		return false, 0, versionedWriteError(err)
+	}
+	if err := s.recordMarkdownOperation(ctx, repos, markdown, previous); err != nil {
+		return false, 0, err
 	}
 
-	// 发布内容更新事件（用于缓存失效）
-	s.dispatchAsync(ctx, NewArticleContentUpdatedEvent(articleID, "markdown"))
-	return nil
+	return true, markdown.Version, s.recordRevision(ctx, repos, articleID, model.ArticleTypeMarkdown, content, changeType, restoredFrom)
 }
 
 // ==================== 文件夹相关操作 ====================
 
-// MoveToFolder 移动文章到指定文件夹
+// MoveToFolder 移动文章到指定文件夹（需要文章 owner 及目标文件夹 editor）
 // 注意：folder 有效性验证由应用层/聚合层负责
 func (s *Service) MoveToFolder(ctx context.Context, articleID uint, folderID *uint) error {
-	article, err := s.GetArticle(ctx, articleID)
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
 	if err != nil {
 		return err
 	}
+	if err := s.authorizeMove(ctx, article, folderID); err != nil {
+		return err
+	}
 	oldFolderID := article.FolderID // 保存移动前的 folderID
 
 	article.FolderID = folderID
 	article.UpdatedAt = time.Now()
 
-	if err := s.articleRepo.Update(ctx, article); err != nil {
+	if err := s.articleRepo.Update(ctx, article, article.Status, "folder_id"); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return ErrConflict.WithMsg("文章状态已变化，请刷新后重试")
+		}
 		s.logger.ErrorCtx(ctx, "移动文章到文件夹失败", zap.Uint("article_id", articleID), zap.Error(err))
 		return ErrDatabaseError.Wrap(err)
 	}
@@ -676,6 +1034,7 @@ func (s *Service) MoveToFolder(ctx context.Context, articleID uint, folderID *ui
 	// 发布文章移动事件（如果 folderID 有变化）
 	if !equalFolderID(oldFolderID, folderID) {
 		s.dispatchAsync(ctx, NewArticleMovedEvent(articleID, oldFolderID, folderID))
+		s.indexArticle(ctx, articleID)
 	}
 
 	return nil
@@ -700,13 +1059,33 @@ func (s *Service) dispatchAsync(ctx context.Context, e event.Event) {
 }
 
 // CountByFolder 统计指定文件夹下的文章数量
+// 启用访问控制时只统计当前主体可见的文章。
 func (s *Service) CountByFolder(ctx context.Context, folderID uint) (int64, error) {
-	return s.articleRepo.CountByFolderID(ctx, folderID)
+	if !s.aclEnabled() {
+		return s.articleRepo.CountByFolderID(ctx, folderID)
+	}
+
+	q := &ArticleQuery{FolderIDs: []uint{folderID}, PageSize: 1}
+	if err := q.Normalize(); err != nil {
+		return 0, err
+	}
+	if err := s.restrictVisible(ctx, q); err != nil {
+		return 0, err
+	}
+	_, total, err := s.articleRepo.Find(ctx, q)
+	if err != nil {
+		return 0, ErrDatabaseError.Wrap(err)
+	}
+	return total, nil
 }
 
-// ListByFolder 获取指定文件夹下的文章
+// ListByFolder 获取指定文件夹下的文章（启用访问控制时过滤不可见的文章）
 func (s *Service) ListByFolder(ctx context.Context, folderID uint) ([]model.Article, error) {
-	return s.articleRepo.FindByFolderID(ctx, folderID)
+	articles, err := s.articleRepo.FindByFolderID(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.filterVisible(ctx, articles)
 }
 
 // ==================== 辅助函数 ====================
@@ -714,3 +1093,19 @@ func (s *Service) ListByFolder(ctx context.Context, folderID uint) ([]model.Arti
 func isValidArticleType(t string) bool {
 	return t == model.ArticleTypeTable || t == model.ArticleTypeMarkdown || t == model.ArticleTypeRichText
 }
+
+// checkVersion 校验调用方提交的版本，expected <= 0 表示不校验
+func checkVersion(current, expected int) error {
+	if expected > 0 && current != expected {
+		return ErrConflict.WithMsgf("内容已被他人修改（当前版本 %d，提交版本 %d），请刷新后重试", current, expected)
+	}
+	return nil
+}
+
+// versionedWriteError 转换按版本条件写入的错误
+func versionedWriteError(err error) error {
+	if errors.Is(err, ErrStaleVersion) {
+		return ErrConflict
+	}
+	return ErrDatabaseError.Wrap(err)
+}
@@ -0,0 +1,300 @@
+package article
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 分享链接 ====================
+
+// 分享密码哈希参数（PBKDF2-HMAC-SHA256）
+const (
+	sharePasswordIterations = 600000
+	sharePasswordSaltSize   = 16
+	sharePasswordKeySize    = 32
+	shareTokenSize          = 32 // 随机字节数，编码后 43 个字符
+
+	// 每个链接连续输错密码 shareMaxPasswordFailures 次后锁定 sharePasswordLockout，
+	// 锁定期内不再计算哈希，避免暴力猜测与借 PBKDF2 消耗 CPU
+	shareMaxPasswordFailures = 5
+	sharePasswordLockout     = 15 * time.Minute
+)
+
+// WithShareLinkRepository 注入分享链接仓储，启用分享链接
+func WithShareLinkRepository(r ArticleShareLinkRepository) ServiceOption {
+	return func(s *Service) {
+		s.shareLinkRepo = r
+	}
+}
+
+// CreateShareLinkInput 创建分享链接输入
+type CreateShareLinkInput struct {
+	ExpiresAt *time.Time // 过期时间，nil 表示永不过期
+	Password  string     // 访问密码，为空表示无需密码
+	MaxViews  int        // 最大访问次数，0 表示不限
+}
+
+// CreateShareLink 为文章创建只读分享链接（需要 owner）
+func (s *Service) CreateShareLink(ctx context.Context, articleID uint, input *CreateShareLinkInput) (*model.ArticleShareLink, error) {
+	if err := s.requireShareLinkRepo(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		return nil, ErrBadRequest.WithMsg("过期时间必须晚于当前时间")
+	}
+	if input.MaxViews < 0 {
+		return nil, ErrBadRequest.WithMsg("最大访问次数不能为负数")
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	link := &model.ArticleShareLink{
+		ArticleID: articleID,
+		Token:     token,
+		ExpiresAt: input.ExpiresAt,
+		MaxViews:  input.MaxViews,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if input.Password != "" {
+		if link.PasswordHash, err = hashSharePassword(input.Password); err != nil {
+			return nil, ErrDatabaseError.Wrap(err)
+		}
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		link.CreatedBy = p.ID
+	}
+
+	if err := s.shareLinkRepo.Create(ctx, link); err != nil {
+		s.logger.ErrorCtx(ctx, "创建分享链接失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	s.logger.InfoCtx(ctx, "分享链接创建成功", zap.Uint("article_id", articleID), zap.Uint("link_id", link.ID))
+	return link, nil
+}
+
+// ListShareLinks 查询文章的全部分享链接（需要 owner）
+func (s *Service) ListShareLinks(ctx context.Context, articleID uint) ([]model.ArticleShareLink, error) {
+	if err := s.requireShareLinkRepo(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shareLinkRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return links, nil
+}
+
+// RevokeShareLink 撤销分享链接（需要文章 owner）
+func (s *Service) RevokeShareLink(ctx context.Context, linkID uint) error {
+	if err := s.requireShareLinkRepo(); err != nil {
+		return err
+	}
+
+	link, err := s.shareLinkRepo.FindByID(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("分享链接不存在")
+		}
+		return ErrDatabaseError.Wrap(err)
+	}
+	if _, err := s.getArticleFor(ctx, link.ArticleID, model.RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.shareLinkRepo.Revoke(ctx, linkID, time.Now()); err != nil {
+		s.logger.ErrorCtx(ctx, "撤销分享链接失败", zap.Uint("link_id", linkID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+
+	s.logger.InfoCtx(ctx, "分享链接已撤销", zap.Uint("link_id", linkID), zap.Uint("article_id", link.ArticleID))
+	return nil
+}
+
+// SharedArticle 通过分享链接访问到的文章内容（按文章类型填充其一）
+type SharedArticle struct {
+	Article  *model.Article          `json:"article"`
+	Markdown *MarkdownArticleContent `json:"markdown,omitempty"`
+	RichText *RichTextArticleContent `json:"richText,omitempty"`
+	Table    *TableArticleContent    `json:"table,omitempty"`
+}
+
+// ResolveShareLink 通过分享令牌读取文章内容（无需登录），只能访问已发布的文章
+// 校验撤销、过期与密码后读取内容，读取成功才计入访问次数；密码错误不计数，但连续错误过多时链接暂时锁定。
+func (s *Service) ResolveShareLink(ctx context.Context, token, password string) (*SharedArticle, error) {
+	if err := s.requireShareLinkRepo(); err != nil {
+		return nil, err
+	}
+
+	link, err := s.shareLinkRepo.FindByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("分享链接不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	switch {
+	case link.IsRevoked():
+		return nil, ErrForbidden.WithMsg("分享链接已失效")
+	case link.IsExpired(time.Now()):
+		return nil, ErrForbidden.WithMsg("分享链接已过期")
+	case link.ViewsExhausted():
+		return nil, ErrForbidden.WithMsg("分享链接访问次数已用完")
+	}
+	if link.HasPassword() {
+		if password == "" {
+			return nil, ErrForbidden.WithMsg("需要访问密码")
+		}
+		if err := s.verifyShareLinkPassword(ctx, link, password); err != nil {
+			return nil, err
+		}
+	}
+
+	// 链接本身即授权凭证，读取内容时跳过访问控制
+	shared, err := s.sharedContent(withSystemAccess(ctx), link.ArticleID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.shareLinkRepo.IncrementViewCount(ctx, link.ID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if !ok {
+		// 并发访问时次数可能在校验后被用完，或链接刚被撤销
+		return nil, ErrForbidden.WithMsg("分享链接访问次数已用完")
+	}
+	return shared, nil
+}
+
+// verifyShareLinkPassword 先预记一次错误再计算哈希，并发请求也无法超出错误次数限制；验证成功后清零
+func (s *Service) verifyShareLinkPassword(ctx context.Context, link *model.ArticleShareLink, password string) error {
+	now := time.Now()
+	if link.PasswordLocked(now, shareMaxPasswordFailures, sharePasswordLockout) {
+		return ErrForbidden.WithMsg("密码错误次数过多，请稍后再试")
+	}
+	ok, err := s.shareLinkRepo.ReservePasswordAttempt(ctx, link.ID, now, shareMaxPasswordFailures, sharePasswordLockout)
+	if err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	if !ok {
+		return ErrForbidden.WithMsg("密码错误次数过多，请稍后再试")
+	}
+
+	if !verifySharePassword(link.PasswordHash, password) {
+		return ErrForbidden.WithMsg("访问密码错误")
+	}
+	if err := s.shareLinkRepo.ResetPasswordFailures(ctx, link.ID); err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) sharedContent(ctx context.Context, articleID uint) (*SharedArticle, error) {
+	article, err := s.findArticle(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	// 草稿（含已下线、待定时发布）不对外分享
+	if !article.IsPublished() {
+		return nil, ErrForbidden.WithMsg("文章未发布")
+	}
+
+	shared := &SharedArticle{Article: article}
+	switch article.ArticleType {
+	case model.ArticleTypeMarkdown:
+		shared.Markdown, err = s.GetMarkdownArticleContent(ctx, articleID)
+	case model.ArticleTypeRichText:
+		shared.RichText, err = s.GetRichTextArticleContent(ctx, articleID)
+	case model.ArticleTypeTable:
+		shared.Table, err = s.GetTableArticleContent(ctx, articleID)
+	default:
+		return nil, ErrBadRequest.WithMsgf("不支持的文章类型: %s", article.ArticleType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return shared, nil
+}
+
+func (s *Service) requireShareLinkRepo() error {
+	if s.shareLinkRepo == nil {
+		return ErrBadRequest.WithMsg("未启用分享链接")
+	}
+	return nil
+}
+
+// newShareToken 生成 URL 安全的随机令牌
+func newShareToken() (string, error) {
+	b := make([]byte, shareTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSharePassword 生成密码哈希，格式：pbkdf2-sha256$迭代次数$盐$摘要
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, sharePasswordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIterations, sharePasswordKeySize)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", sharePasswordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifySharePassword 校验密码（迭代次数取自哈希，便于日后调整参数）
+func verifySharePassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
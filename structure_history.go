@@ -0,0 +1,254 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 表格结构版本 ====================
+
+// ListTableStructureVersions 获取表格结构的全部历史版本（按版本号倒序）
+func (s *Service) ListTableStructureVersions(ctx context.Context, articleID uint) ([]model.TableArticleStructureHistory, error) {
+	if _, err := s.getTableWithHistory(ctx, articleID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	histories, err := s.historyRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "查询表格结构历史失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return histories, nil
+}
+
+// ColumnChange 单列变更
+type ColumnChange struct {
+	Field  string                 `json:"field"`
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+}
+
+// StructureDiff 两个结构版本之间的差异（按列 field 对比）
+type StructureDiff struct {
+	FromVersion  int                      `json:"fromVersion"`
+	ToVersion    int                      `json:"toVersion"`
+	Added        []map[string]interface{} `json:"added"`
+	Removed      []map[string]interface{} `json:"removed"`
+	Modified     []ColumnChange           `json:"modified"`
+	OrderChanged bool                     `json:"orderChanged"`
+}
+
+// IsEmpty 是否无差异
+func (d *StructureDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 && !d.OrderChanged
+}
+
+// Summary 生成可读的变更描述
+func (d *StructureDiff) Summary() string {
+	if d.IsEmpty() {
+		return "结构无变化"
+	}
+	var parts []string
+	if len(d.Added) > 0 {
+		parts = append(parts, "新增列: "+strings.Join(columnFields(d.Added), ", "))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, "删除列: "+strings.Join(columnFields(d.Removed), ", "))
+	}
+	if len(d.Modified) > 0 {
+		fields := make([]string, len(d.Modified))
+		for i, c := range d.Modified {
+			fields[i] = c.Field
+		}
+		parts = append(parts, "修改列: "+strings.Join(fields, ", "))
+	}
+	if d.OrderChanged {
+		parts = append(parts, "调整列顺序")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DiffTableStructure 对比表格两个结构版本
+func (s *Service) DiffTableStructure(ctx context.Context, articleID uint, fromVersion, toVersion int) (*StructureDiff, error) {
+	if _, err := s.getTableWithHistory(ctx, articleID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	from, err := s.findStructureVersion(ctx, articleID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.findStructureVersion(ctx, articleID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffStructure(from.Structure, to.Structure)
+	diff.FromVersion = fromVersion
+	diff.ToVersion = toVersion
+	return diff, nil
+}
+
+// RollbackTableStructure 将表格结构回滚到指定版本
+// 回滚会生成一个新版本而不是删除中间历史；行数据不受影响。
+func (s *Service) RollbackTableStructure(ctx context.Context, articleID uint, version int) error {
+	tableArticle, err := s.getTableWithHistory(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return err
+	}
+	if version == tableArticle.Version {
+		return ErrBadRequest.WithMsg("已是当前版本")
+	}
+
+	target, err := s.findStructureVersion(ctx, articleID, version)
+	if err != nil {
+		return err
+	}
+
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		return s.applyTableStructure(ctx, repos, tableArticle, target.Structure, model.StructureChangeRollback, fmt.Sprintf("回滚到版本 %d", version))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoCtx(ctx, "表格结构回滚成功", zap.Uint("article_id", articleID), zap.Int("version", version))
+	return nil
+}
+
+// getTableWithHistory 获取表格结构（要求启用结构历史）
+func (s *Service) getTableWithHistory(ctx context.Context, articleID uint, minRole string) (*model.TableArticle, error) {
+	if s.historyRepo == nil {
+		return nil, ErrBadRequest.WithMsg("未启用表格结构历史")
+	}
+
+	article, err := s.getArticleFor(ctx, articleID, minRole)
+	if err != nil {
+		return nil, err
+	}
+	if article.ArticleType != model.ArticleTypeTable {
+		return nil, ErrBadRequest.WithMsg("该文章不是表格类型")
+	}
+
+	tableArticle, err := s.tableRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("表格不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return tableArticle, nil
+}
+
+func (s *Service) findStructureVersion(ctx context.Context, articleID uint, version int) (*model.TableArticleStructureHistory, error) {
+	history, err := s.historyRepo.FindByVersion(ctx, articleID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsgf("结构版本不存在: %d", version)
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return history, nil
+}
+
+// recordStructureHistory 记录表格当前结构（未注入历史仓储时跳过）
+func (s *Service) recordStructureHistory(ctx context.Context, repos *Repositories, tableArticle *model.TableArticle, changeType, description string) error {
+	if repos.StructureHistory == nil {
+		return nil
+	}
+
+	history := &model.TableArticleStructureHistory{
+		ArticleID:         tableArticle.ArticleID,
+		Version:           tableArticle.Version,
+		Structure:         tableArticle.Structure,
+		ChangeType:        changeType,
+		ChangeDescription: description,
+		CreatedAt:         time.Now(),
+	}
+	if err := repos.StructureHistory.Create(ctx, history); err != nil {
+		s.logger.ErrorCtx(ctx, "记录表格结构历史失败", zap.Uint("article_id", tableArticle.ArticleID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+// ensureStructureBaseline 当前版本缺少历史记录时补记一条基线
+func (s *Service) ensureStructureBaseline(ctx context.Context, repos *Repositories, tableArticle *model.TableArticle) error {
+	if repos.StructureHistory == nil {
+		return nil
+	}
+
+	_, err := repos.StructureHistory.FindByVersion(ctx, tableArticle.ArticleID, tableArticle.Version)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDatabaseError.Wrap(err)
+	}
+	return s.recordStructureHistory(ctx, repos, tableArticle, model.StructureChangeBaseline, "历史基线")
+}
+
+// diffStructure 按列 field 对比两个结构
+func diffStructure(from, to model.JSONArray) *StructureDiff {
+	diff := &StructureDiff{}
+
+	fromByField := make(map[string]map[string]interface{}, len(from))
+	var fromOrder []string
+	for _, col := range from {
+		field := columnField(col)
+		fromByField[field] = col
+		fromOrder = append(fromOrder, field)
+	}
+
+	var commonOrder []string
+	toFields := make(map[string]bool, len(to))
+	for _, col := range to {
+		field := columnField(col)
+		toFields[field] = true
+		before, ok := fromByField[field]
+		if !ok {
+			diff.Added = append(diff.Added, col)
+			continue
+		}
+		commonOrder = append(commonOrder, field)
+		if !reflect.DeepEqual(before, map[string]interface{}(col)) {
+			diff.Modified = append(diff.Modified, ColumnChange{Field: field, Before: before, After: col})
+		}
+	}
+
+	var keptOrder []string
+	for _, field := range fromOrder {
+		if !toFields[field] {
+			diff.Removed = append(diff.Removed, fromByField[field])
+			continue
+		}
+		keptOrder = append(keptOrder, field)
+	}
+
+	diff.OrderChanged = !reflect.DeepEqual(keptOrder, commonOrder)
+	return diff
+}
+
+func columnField(col map[string]interface{}) string {
+	field, _ := col["field"].(string)
+	return field
+}
+
+func columnFields(cols []map[string]interface{}) []string {
+	fields := make([]string, len(cols))
+	for i, col := range cols {
+		fields[i] = columnField(col)
+	}
+	return fields
+}
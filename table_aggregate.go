@@ -0,0 +1,330 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+)
+
+// 聚合函数
+const (
+	AggCount    = "count"    // 非空单元格数；Field 为空时为行数
+	AggSum      = "sum"      // 仅 number / integer / formula 列，非数值忽略
+	AggAvg      = "avg"      // 仅 number / integer / formula 列，非数值忽略
+	AggMin      = "min"      // 多选列除外
+	AggMax      = "max"      // 多选列除外
+	AggDistinct = "distinct" // 不同值个数；多选列按选项计
+)
+
+// 聚合限制
+const (
+	MaxAggregations   = 50
+	MaxGroupByColumns = 5
+	MaxAggregateGroup = 10000
+)
+
+// Aggregation 单个聚合项
+type Aggregation struct {
+	Field string `json:"field"`
+	Func  string `json:"func"`
+	Alias string `json:"alias,omitempty"` // 结果键名，默认为 "func(field)"，无列时为 "func"
+}
+
+// key 结果中的键名
+func (a Aggregation) key() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	if a.Field == "" {
+		return a.Func
+	}
+	return fmt.Sprintf("%s(%s)", a.Func, a.Field)
+}
+
+// AggregateTableInput 表格聚合条件
+type AggregateTableInput struct {
+	GroupBy            []string      // 分组列，为空时整表为一组
+	Aggregations       []Aggregation // 为空时只统计每组行数
+	Filter             *RowFilter    // 与保存的 Filters 之间为 AND
+	IgnoreSavedFilters bool
+}
+
+// AggregateGroup 一个分组的聚合结果
+type AggregateGroup struct {
+	Keys   map[string]interface{} `json:"keys"`   // 分组列取值，空单元格为 nil
+	Count  int64                  `json:"count"`  // 组内行数
+	Values map[string]interface{} `json:"values"` // 按 Aggregation.key 索引；无可用值时为 nil
+}
+
+// AggregateResult 表格聚合结果，分组按分组列取值升序（空值在后）
+type AggregateResult struct {
+	Groups []AggregateGroup `json:"groups"`
+	Total  int64            `json:"total"` // 参与聚合的行数
+}
+
+// AggregateTable 按列统计表格行（需要 viewer）
+func (s *Service) AggregateTable(ctx context.Context, articleID uint, input *AggregateTableInput) (*AggregateResult, error) {
+	table, schema, err := s.queryableTable(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAggregateInput(schema, input); err != nil {
+		return nil, err
+	}
+	filter, err := combineRowFilters(table, schema, input.Filter, input.IgnoreSavedFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AggregateResult{}
+	groups := make(map[string]*aggregateGroup)
+	var order []*aggregateGroup
+	err = s.eachTableRow(ctx, table, func(row model.TableArticleRow) error {
+		if filter != nil && !filter.Match(row.RowData) {
+			return nil
+		}
+
+		keys := make([]interface{}, len(input.GroupBy))
+		for i, field := range input.GroupBy {
+			keys[i] = groupKeyValue(row.RowData[field])
+		}
+		id, err := json.Marshal(keys)
+		if err != nil {
+			return ErrBadRequest.WithMsgf("分组值无法比较: %v", err)
+		}
+		g, ok := groups[string(id)]
+		if !ok {
+			if len(groups) >= MaxAggregateGroup {
+				return ErrBadRequest.WithMsgf("分组数不能超过 %d", MaxAggregateGroup)
+			}
+			g = newAggregateGroup(keys, input.Aggregations)
+			groups[string(id)] = g
+			order = append(order, g)
+		}
+
+		result.Total++
+		g.add(row.RowData, input.Aggregations)
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "表格聚合失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, err
+	}
+
+	// 不分组时即使没有命中行也返回一组（计数为 0）
+	if len(input.GroupBy) == 0 && len(order) == 0 {
+		order = append(order, newAggregateGroup(nil, input.Aggregations))
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		for k := range input.GroupBy {
+			a, b := order[i].keys[k], order[j].keys[k]
+			aEmpty, bEmpty := isEmptyCell(a), isEmptyCell(b)
+			switch {
+			case aEmpty && bEmpty:
+				continue
+			case aEmpty:
+				return false
+			case bEmpty:
+				return true
+			}
+			if c, ok := compareCells(a, b); ok && c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	result.Groups = make([]AggregateGroup, len(order))
+	for i, g := range order {
+		result.Groups[i] = g.result(input.GroupBy, input.Aggregations, schema)
+	}
+	return result, nil
+}
+
+func validateAggregateInput(schema *TableSchema, input *AggregateTableInput) error {
+	if len(input.GroupBy) > MaxGroupByColumns {
+		return ErrBadRequest.WithMsgf("分组列不能超过 %d 个", MaxGroupByColumns)
+	}
+	if len(input.Aggregations) > MaxAggregations {
+		return ErrBadRequest.WithMsgf("聚合项不能超过 %d 个", MaxAggregations)
+	}
+	typed := len(schema.Columns) > 0
+
+	seen := make(map[string]bool, len(input.GroupBy))
+	for _, field := range input.GroupBy {
+		if field == "" || seen[field] {
+			return ErrBadRequest.WithMsgf("分组列无效: %q", field)
+		}
+		seen[field] = true
+		if !typed {
+			continue
+		}
+		col, ok := schema.Column(field)
+		if !ok {
+			return ErrBadRequest.WithMsgf("分组列不存在: %s", field)
+		}
+		if col.Type == model.ColumnTypeMultiSelect {
+			return ErrBadRequest.WithMsgf("多选列不能用于分组: %s", field)
+		}
+	}
+
+	keys := make(map[string]bool, len(input.Aggregations))
+	for _, agg := range input.Aggregations {
+		switch agg.Func {
+		case AggCount, AggSum, AggAvg, AggMin, AggMax, AggDistinct:
+		default:
+			return ErrBadRequest.WithMsgf("不支持的聚合函数: %s", agg.Func)
+		}
+		if agg.Field == "" && agg.Func != AggCount {
+			return ErrBadRequest.WithMsgf("聚合函数 %s 缺少列", agg.Func)
+		}
+		if keys[agg.key()] {
+			return ErrBadRequest.WithMsgf("聚合项重复: %s", agg.key())
+		}
+		keys[agg.key()] = true
+		if !typed || agg.Field == "" {
+			continue
+		}
+
+		col, ok := schema.Column(agg.Field)
+		if !ok {
+			return ErrBadRequest.WithMsgf("聚合列不存在: %s", agg.Field)
+		}
+		switch agg.Func {
+		case AggSum, AggAvg:
+			if col.Type != model.ColumnTypeNumber && col.Type != model.ColumnTypeInteger && !col.IsComputed() {
+				return ErrBadRequest.WithMsgf("列 %s 不是数值类型，不能计算 %s", agg.Field, agg.Func)
+			}
+		case AggMin, AggMax:
+			if col.Type == model.ColumnTypeMultiSelect {
+				return ErrBadRequest.WithMsgf("多选列不能计算 %s: %s", agg.Func, agg.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// groupKeyValue 统一分组取值：数值统一为 float64，避免 3 与 3.0 分到不同组
+func groupKeyValue(v interface{}) interface{} {
+	if isEmptyCell(v) {
+		return nil
+	}
+	if n, ok := numericValue(v); ok {
+		return n
+	}
+	return v
+}
+
+// aggregateGroup 单个分组的累加状态
+type aggregateGroup struct {
+	keys  []interface{}
+	count int64
+	accs  []aggregateAcc
+}
+
+type aggregateAcc struct {
+	count    int64
+	sum      float64
+	numbers  int64
+	min, max interface{}
+	distinct map[string]bool
+}
+
+func newAggregateGroup(keys []interface{}, aggs []Aggregation) *aggregateGroup {
+	g := &aggregateGroup{keys: keys, accs: make([]aggregateAcc, len(aggs))}
+	for i, agg := range aggs {
+		if agg.Func == AggDistinct {
+			g.accs[i].distinct = make(map[string]bool)
+		}
+	}
+	return g
+}
+
+func (g *aggregateGroup) add(row map[string]interface{}, aggs []Aggregation) {
+	g.count++
+	for i, agg := range aggs {
+		acc := &g.accs[i]
+		if agg.Field == "" {
+			acc.count++
+			continue
+		}
+		v := row[agg.Field]
+		if isEmptyCell(v) {
+			continue
+		}
+		acc.count++
+
+		switch agg.Func {
+		case AggSum, AggAvg:
+			if n, ok := numericValue(v); ok {
+				acc.sum += n
+				acc.numbers++
+			}
+		case AggMin:
+			if acc.min == nil {
+				acc.min = v
+			} else if c, ok := compareCells(v, acc.min); ok && c < 0 {
+				acc.min = v
+			}
+		case AggMax:
+			if acc.max == nil {
+				acc.max = v
+			} else if c, ok := compareCells(v, acc.max); ok && c > 0 {
+				acc.max = v
+			}
+		case AggDistinct:
+			if items, ok := stringList(v); ok {
+				for _, item := range items {
+					acc.distinct[item] = true
+				}
+				continue
+			}
+			if b, err := json.Marshal(groupKeyValue(v)); err == nil {
+				acc.distinct[string(b)] = true
+			}
+		}
+	}
+}
+
+func (g *aggregateGroup) result(groupBy []string, aggs []Aggregation, schema *TableSchema) AggregateGroup {
+	out := AggregateGroup{
+		Keys:   make(map[string]interface{}, len(groupBy)),
+		Count:  g.count,
+		Values: make(map[string]interface{}, len(aggs)),
+	}
+	for i, field := range groupBy {
+		out.Keys[field] = g.keys[i]
+	}
+
+	for i, agg := range aggs {
+		acc := &g.accs[i]
+		var value interface{}
+		switch agg.Func {
+		case AggCount:
+			value = acc.count
+		case AggSum:
+			value = acc.sum
+			if col, ok := schema.Column(agg.Field); ok && col.Type == model.ColumnTypeInteger && acc.sum == math.Trunc(acc.sum) {
+				value = int64(acc.sum)
+			}
+		case AggAvg:
+			if acc.numbers > 0 {
+				value = acc.sum / float64(acc.numbers)
+			}
+		case AggMin:
+			value = acc.min
+		case AggMax:
+			value = acc.max
+		case AggDistinct:
+			value = int64(len(acc.distinct))
+		}
+		out.Values[agg.key()] = value
+	}
+	return out
+}
@@ -0,0 +1,507 @@
+package article
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"gorm.io/gorm"
+)
+
+// ==================== 表格导入导出 ====================
+
+// 导入限制
+const (
+	MaxImportRows     = 50000
+	MaxImportColumns  = 500
+	MaxImportXLSXSize = 200 << 20 // XLSX 解压后的总字节数
+	exportBatchSize   = 500
+)
+
+var (
+	errTooManyImportRows    = errors.New("too many import rows")
+	errTooManyImportColumns = errors.New("too many import columns")
+)
+
+// utf8BOM 导出 CSV 时写入 BOM，便于 Excel 正确识别中文
+const utf8BOM = "\xef\xbb\xbf"
+
+// ImportTableInput 导入表格输入（首行为表头）
+type ImportTableInput struct {
+	Title     string
+	TableID   string // 为空时自动生成
+	FolderID  *uint
+	OwnerID   uint
+	OwnerType string
+	Sheet     string // XLSX 工作表名，为空时取第一个
+}
+
+// ImportTableFromCSV 从 CSV 创建表格文章：表头生成列结构并推断列类型，其余行写入数据
+func (s *Service) ImportTableFromCSV(ctx context.Context, input *ImportTableInput, r io.Reader) (*model.Article, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // 允许各行列数不一致
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrBadRequest.WithMsgf("CSV 格式错误: %v", err)
+		}
+		if len(records) == 0 && len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], utf8BOM)
+		}
+		if len(records) > MaxImportRows {
+			return nil, ErrBadRequest.WithMsgf("导入行数不能超过 %d", MaxImportRows)
+		}
+		records = append(records, record)
+	}
+	return s.importTable(ctx, input, records)
+}
+
+// ImportTableFromXLSX 从 XLSX 工作表创建表格文章（规则同 ImportTableFromCSV）
+// 日期格式的单元格按日期/时间导入，公式单元格取缓存的计算结果。
+func (s *Service) ImportTableFromXLSX(ctx context.Context, input *ImportTableInput, r io.ReaderAt, size int64) (*model.Article, error) {
+	x, err := newXLSXReader(r, size, MaxImportXLSXSize)
+	if errors.Is(err, errXLSXTooLarge) {
+		return nil, ErrBadRequest.WithMsgf("XLSX 解压后不能超过 %d 字节", MaxImportXLSXSize)
+	}
+	if err != nil {
+		return nil, ErrBadRequest.WithMsgf("XLSX 格式错误: %v", err)
+	}
+
+	var records [][]string
+	err = x.readSheet(input.Sheet, MaxImportColumns, func(cells []string) error {
+		if len(records) > MaxImportRows {
+			return errTooManyImportRows
+		}
+		records = append(records, cells)
+		return nil
+	})
+	switch {
+	case errors.Is(err, errTooManyImportRows):
+		return nil, ErrBadRequest.WithMsgf("导入行数不能超过 %d", MaxImportRows)
+	case errors.Is(err, errTooManyImportColumns):
+		return nil, ErrBadRequest.WithMsgf("导入列数不能超过 %d", MaxImportColumns)
+	case errors.Is(err, errXLSXTooLarge):
+		return nil, ErrBadRequest.WithMsgf("XLSX 解压后不能超过 %d 字节", MaxImportXLSXSize)
+	case err != nil:
+		return nil, ErrBadRequest.WithMsgf("XLSX 格式错误: %v", err)
+	}
+	return s.importTable(ctx, input, records)
+}
+
+// importTable 由表头与数据行构建结构并创建表格文章
+func (s *Service) importTable(ctx context.Context, input *ImportTableInput, records [][]string) (*model.Article, error) {
+	records = dropEmptyRecords(records)
+	if len(records) == 0 {
+		return nil, ErrBadRequest.WithMsg("导入文件为空")
+	}
+	header, rows := records[0], records[1:]
+	width := len(header)
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if width > MaxImportColumns {
+		return nil, ErrBadRequest.WithMsgf("导入列数不能超过 %d", MaxImportColumns)
+	}
+
+	fields := importFieldNames(header, width)
+	structure := make([]map[string]interface{}, width)
+	types := make([]string, width)
+	for col := 0; col < width; col++ {
+		title := ""
+		if col < len(header) {
+			title = strings.TrimSpace(header[col])
+		}
+		if title == "" {
+			title = fmt.Sprintf("列%d", col+1)
+		}
+		types[col] = inferColumnType(rows, col)
+		structure[col] = map[string]interface{}{
+			"field": fields[col],
+			"title": title,
+			"type":  types[col],
+		}
+	}
+
+	data := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		rowData := make(map[string]interface{}, width)
+		for col, raw := range row {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			rowData[fields[col]] = convertImportedValue(raw, types[col])
+		}
+		data[i] = rowData
+	}
+
+	tableID := input.TableID
+	if tableID == "" {
+		tableID = newTableID()
+	}
+	return s.CreateTableArticle(ctx, &CreateTableArticleInput{
+		Title:       input.Title,
+		TableID:     tableID,
+		FolderID:    input.FolderID,
+		OwnerID:     input.OwnerID,
+		OwnerType:   input.OwnerType,
+		Structure:   structure,
+		ColumnOrder: fields,
+		Data:        data,
+	})
+}
+
+// ExportTableAsCSV 按列顺序与列标题流式导出 CSV（首行为表头）
+func (s *Service) ExportTableAsCSV(ctx context.Context, articleID uint, w io.Writer) error {
+	table, columns, err := s.exportColumns(ctx, articleID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Title
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	err = s.eachTableRow(ctx, table, func(row model.TableArticleRow) error {
+		for i, col := range columns {
+			record[i] = formatCellText(row.RowData[col.Field])
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportTableAsXLSX 按列顺序与列标题流式导出 XLSX（单工作表，首行为加粗表头）
+func (s *Service) ExportTableAsXLSX(ctx context.Context, articleID uint, w io.Writer) error {
+	table, columns, err := s.exportColumns(ctx, articleID)
+	if err != nil {
+		return err
+	}
+	article, err := s.findArticle(ctx, articleID)
+	if err != nil {
+		return err
+	}
+
+	xw, err := newXLSXWriter(w, xlsxSheetName(article.Title))
+	if err != nil {
+		return err
+	}
+	header := make([]any, len(columns))
+	for i, col := range columns {
+		header[i] = col.Title
+	}
+	if err := xw.WriteRow(header, true); err != nil {
+		return err
+	}
+
+	values := make([]any, len(columns))
+	err = s.eachTableRow(ctx, table, func(row model.TableArticleRow) error {
+		for i, col := range columns {
+			values[i] = xlsxCellValue(row.RowData[col.Field])
+		}
+		return xw.WriteRow(values, false)
+	})
+	if err != nil {
+		return err
+	}
+	return xw.Close()
+}
+
+// exportColumn 导出列
+type exportColumn struct {
+	Field string
+	Title string
+	Type  string
+}
+
+// exportColumns 获取表格结构（需要 viewer）并确定导出列：先按 ColumnOrder，再补充未列出的结构列
+func (s *Service) exportColumns(ctx context.Context, articleID uint) (*model.TableArticle, []exportColumn, error) {
+	article, err := s.GetArticle(ctx, articleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if article.ArticleType != model.ArticleTypeTable {
+		return nil, nil, ErrBadRequest.WithMsg("该文章不是表格类型")
+	}
+	table, err := s.tableRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNotFound.WithMsg("表格不存在")
+		}
+		return nil, nil, ErrDatabaseError.Wrap(err)
+	}
+	return table, orderedColumns(table), nil
+}
+
+func orderedColumns(table *model.TableArticle) []exportColumn {
+	byField := make(map[string]exportColumn, len(table.Structure))
+	var structOrder []string
+	for _, def := range table.Structure {
+		field := columnField(def)
+		if field == "" {
+			continue
+		}
+		col := exportColumn{Field: field, Title: field}
+		if title, ok := def["title"].(string); ok && title != "" {
+			col.Title = title
+		}
+		col.Type, _ = def["type"].(string)
+		byField[field] = col
+		structOrder = append(structOrder, field)
+	}
+
+	var columns []exportColumn
+	seen := make(map[string]bool)
+	for _, entry := range table.ColumnOrder {
+		field, _ := entry["field"].(string)
+		col, ok := byField[field]
+		if !ok || seen[field] {
+			continue
+		}
+		seen[field] = true
+		columns = append(columns, col)
+	}
+	for _, field := range structOrder {
+		if !seen[field] {
+			seen[field] = true
+			columns = append(columns, byField[field])
+		}
+	}
+	return columns
+}
+
+// eachTableRow 分批按行顺序遍历表格数据，公式列已计算
+func (s *Service) eachTableRow(ctx context.Context, table *model.TableArticle, fn func(row model.TableArticleRow) error) error {
+	schema := readSchema(table)
+	for offset := 0; ; offset += exportBatchSize {
+		rows, err := s.tableRowRepo.FindBatch(ctx, table.ArticleID, offset, exportBatchSize)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		for _, row := range rows {
+			if row.RowData == nil {
+				row.RowData = model.JSONMap{}
+			}
+			schema.ApplyFormulas(row.RowData)
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// ==================== 类型推断与格式化 ====================
+
+var (
+	importFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	importDateLayouts  = []string{time.DateOnly, "2006/01/02", "2006/1/2", "2006-1-2"}
+	importTimeLayouts  = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", "2006/01/02 15:04:05", "2006/01/02 15:04", "2006-01-02T15:04:05"}
+)
+
+// importFieldNames 由表头生成列 field：合法标识符直接使用，否则使用 col_N；重名时追加序号
+func importFieldNames(header []string, width int) []string {
+	fields := make([]string, width)
+	used := make(map[string]bool, width)
+	for col := 0; col < width; col++ {
+		name := ""
+		if col < len(header) {
+			name = strings.TrimSpace(header[col])
+		}
+		if !importFieldPattern.MatchString(name) {
+			name = fmt.Sprintf("col_%d", col+1)
+		}
+		base := name
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = true
+		fields[col] = name
+	}
+	return fields
+}
+
+// inferColumnType 根据列中所有非空值推断类型；全部为空时为文本
+func inferColumnType(rows [][]string, col int) string {
+	candidates := []string{model.ColumnTypeBoolean, model.ColumnTypeInteger, model.ColumnTypeNumber, model.ColumnTypeDate, model.ColumnTypeDatetime}
+	seen := false
+	for _, row := range rows {
+		if col >= len(row) {
+			continue
+		}
+		v := strings.TrimSpace(row[col])
+		if v == "" {
+			continue
+		}
+		seen = true
+		kept := candidates[:0]
+		for _, t := range candidates {
+			if _, ok := parseImportedValue(v, t); ok {
+				kept = append(kept, t)
+			}
+		}
+		candidates = kept
+		if len(candidates) == 0 {
+			return model.ColumnTypeText
+		}
+	}
+	if !seen {
+		return model.ColumnTypeText
+	}
+	return candidates[0]
+}
+
+// convertImportedValue 按列类型转换单元格；推断保证转换成功，失败时保留原文
+func convertImportedValue(raw, columnType string) interface{} {
+	if v, ok := parseImportedValue(raw, columnType); ok {
+		return v
+	}
+	return raw
+}
+
+func parseImportedValue(raw, columnType string) (interface{}, bool) {
+	switch columnType {
+	case model.ColumnTypeBoolean:
+		switch strings.ToLower(raw) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	case model.ColumnTypeInteger:
+		if hasLeadingZero(raw) {
+			return nil, false
+		}
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n, true
+		}
+	case model.ColumnTypeNumber:
+		if hasLeadingZero(raw) {
+			return nil, false
+		}
+		if f, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f, true
+		}
+	case model.ColumnTypeDate:
+		for _, layout := range importDateLayouts {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t.Format(time.DateOnly), true
+			}
+		}
+	case model.ColumnTypeDatetime:
+		for _, layout := range importTimeLayouts {
+			if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+				return t.Format(time.RFC3339), true
+			}
+		}
+	case model.ColumnTypeText:
+		return raw, true
+	}
+	return nil, false
+}
+
+// hasLeadingZero 编号、邮编等带前导零的值按文本处理，避免丢失前导零
+func hasLeadingZero(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	return len(s) > 1 && s[0] == '0' && s[1] != '.'
+}
+
+func dropEmptyRecords(records [][]string) [][]string {
+	kept := records[:0]
+	for _, record := range records {
+		for _, v := range record {
+			if strings.TrimSpace(v) != "" {
+				kept = append(kept, record)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// formatCellText 单元格值转为文本（CSV）
+func formatCellText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case int:
+		return strconv.Itoa(val)
+	case json.Number:
+		return val.String()
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = formatCellText(item)
+		}
+		return strings.Join(parts, ", ")
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(b)
+	}
+}
+
+// xlsxCellValue 数值与布尔值保持原类型，其余转为文本
+func xlsxCellValue(v interface{}) any {
+	switch val := v.(type) {
+	case nil, bool, float64, int64:
+		return val
+	case int:
+		return int64(val)
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	default:
+		return formatCellText(val)
+	}
+}
+
+// newTableID 生成随机的表格ID
+func newTableID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "tbl_" + hex.EncodeToString(b)
+}
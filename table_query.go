@@ -0,0 +1,487 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 行过滤运算符
+const (
+	FilterOpEquals         = "eq"
+	FilterOpNotEquals      = "ne"
+	FilterOpContains       = "contains" // 文本为不区分大小写的子串匹配，多选为包含该选项
+	FilterOpGreater        = "gt"
+	FilterOpGreaterOrEqual = "gte"
+	FilterOpLess           = "lt"
+	FilterOpLessOrEqual    = "lte"
+	FilterOpBetween        = "between" // Values 为 [下限, 上限]，闭区间
+	FilterOpIn             = "in"      // 等于 Values 中任一值
+	FilterOpEmpty          = "empty"
+	FilterOpNotEmpty       = "not_empty"
+)
+
+// maxFilterDepth 条件组最大嵌套层数
+const maxFilterDepth = 8
+
+// RowFilter 行过滤表达式
+// 叶子节点为单列条件（Field + Op + Value/Values）；And / Or 为条件组，二者只能设置其一。
+// 与 TableArticle.Filters 中保存的 JSON 格式一致，保存的每一项之间为 AND。
+type RowFilter struct {
+	Field  string        `json:"field,omitempty"`
+	Op     string        `json:"op,omitempty"`
+	Value  interface{}   `json:"value,omitempty"`
+	Values []interface{} `json:"values,omitempty"`
+	And    []RowFilter   `json:"and,omitempty"`
+	Or     []RowFilter   `json:"or,omitempty"`
+}
+
+// RowSort 行排序条件
+type RowSort struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// Validate 校验表达式；schema 有列定义时同时校验列是否存在
+func (f *RowFilter) Validate(schema *TableSchema) error {
+	return f.validate(schema, 0)
+}
+
+func (f *RowFilter) validate(schema *TableSchema, depth int) error {
+	if depth > maxFilterDepth {
+		return fmt.Errorf("条件嵌套不能超过 %d 层", maxFilterDepth)
+	}
+
+	if len(f.And) > 0 || len(f.Or) > 0 {
+		if len(f.And) > 0 && len(f.Or) > 0 {
+			return errors.New("and 与 or 不能同时设置")
+		}
+		if f.Field != "" || f.Op != "" {
+			return errors.New("条件组不能同时设置 field / op")
+		}
+		for i := range f.And {
+			if err := f.And[i].validate(schema, depth+1); err != nil {
+				return err
+			}
+		}
+		for i := range f.Or {
+			if err := f.Or[i].validate(schema, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if f.Field == "" {
+		return errors.New("缺少 field")
+	}
+	if schema != nil && len(schema.Columns) > 0 {
+		if _, ok := schema.Column(f.Field); !ok {
+			return fmt.Errorf("列不存在: %s", f.Field)
+		}
+	}
+	switch f.Op {
+	case FilterOpEquals, FilterOpNotEquals, FilterOpContains,
+		FilterOpGreater, FilterOpGreaterOrEqual, FilterOpLess, FilterOpLessOrEqual:
+		if f.Value == nil {
+			return fmt.Errorf("列 %s 的 %s 条件缺少 value", f.Field, f.Op)
+		}
+	case FilterOpBetween:
+		if len(f.Values) != 2 || f.Values[0] == nil || f.Values[1] == nil {
+			return fmt.Errorf("列 %s 的 between 条件需要 [下限, 上限]", f.Field)
+		}
+	case FilterOpIn:
+		if len(f.Values) == 0 {
+			return fmt.Errorf("列 %s 的 in 条件缺少 values", f.Field)
+		}
+	case FilterOpEmpty, FilterOpNotEmpty:
+	default:
+		return fmt.Errorf("不支持的过滤条件: %s", f.Op)
+	}
+	return nil
+}
+
+// Match 判断行数据是否满足条件
+func (f *RowFilter) Match(row map[string]interface{}) bool {
+	switch {
+	case len(f.And) > 0:
+		for i := range f.And {
+			if !f.And[i].Match(row) {
+				return false
+			}
+		}
+		return true
+	case len(f.Or) > 0:
+		for i := range f.Or {
+			if f.Or[i].Match(row) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cell := row[f.Field]
+	switch f.Op {
+	case FilterOpEmpty:
+		return isEmptyCell(cell)
+	case FilterOpNotEmpty:
+		return !isEmptyCell(cell)
+	case FilterOpEquals:
+		return cellEquals(cell, f.Value)
+	case FilterOpNotEquals:
+		return !cellEquals(cell, f.Value)
+	case FilterOpContains:
+		return cellContains(cell, f.Value)
+	case FilterOpIn:
+		for _, v := range f.Values {
+			if cellEquals(cell, v) {
+				return true
+			}
+		}
+		return false
+	case FilterOpBetween:
+		lo, ok1 := compareCells(cell, f.Values[0])
+		hi, ok2 := compareCells(cell, f.Values[1])
+		return ok1 && ok2 && lo >= 0 && hi <= 0
+	}
+
+	c, ok := compareCells(cell, f.Value)
+	if !ok {
+		return false
+	}
+	switch f.Op {
+	case FilterOpGreater:
+		return c > 0
+	case FilterOpGreaterOrEqual:
+		return c >= 0
+	case FilterOpLess:
+		return c < 0
+	case FilterOpLessOrEqual:
+		return c <= 0
+	}
+	return false
+}
+
+// cellEquals 多选单元格包含该值即视为相等
+func cellEquals(cell, v interface{}) bool {
+	if items, ok := stringList(cell); ok {
+		s, isString := v.(string)
+		if !isString {
+			return false
+		}
+		for _, item := range items {
+			if item == s {
+				return true
+			}
+		}
+		return false
+	}
+	c, ok := compareCells(cell, v)
+	return ok && c == 0
+}
+
+func cellContains(cell, v interface{}) bool {
+	if _, ok := stringList(cell); ok {
+		return cellEquals(cell, v)
+	}
+	if isEmptyCell(cell) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(formatCellText(cell)), strings.ToLower(formatCellText(v)))
+}
+
+// compareCells 比较两个单元格值：数字按数值，日期时间按时间，布尔 false < true，其余按字符串。
+// 类型不可比较（或任一为空）时返回 false。
+func compareCells(a, b interface{}) (int, bool) {
+	if isEmptyCell(a) || isEmptyCell(b) {
+		return 0, false
+	}
+	if x, ok := numericValue(a); ok {
+		y, ok := numericValue(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch x := a.(type) {
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		}
+		return 1, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		if tx, err := time.Parse(time.RFC3339, x); err == nil {
+			if ty, err := time.Parse(time.RFC3339, y); err == nil {
+				return tx.Compare(ty), true
+			}
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+// ==================== 行查询 ====================
+
+// TableRowQuery 表格行查询条件
+type TableRowQuery struct {
+	Filter             *RowFilter // 临时条件，与保存的 Filters 之间为 AND
+	IgnoreSavedFilters bool       // 为 true 时不应用 TableArticle.Filters
+	Sort               []RowSort  // 为空时按行顺序
+
+	Page     int // 从 1 开始，默认 1
+	PageSize int // 默认 DefaultPageSize，最大 MaxPageSize
+}
+
+// Normalize 校验查询条件并填充默认值
+func (q *TableRowQuery) Normalize() error {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Page < 0 {
+		return ErrBadRequest.WithMsg("页码必须大于0")
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 0 || q.PageSize > MaxPageSize {
+		return ErrBadRequest.WithMsgf("每页数量必须在 1 到 %d 之间", MaxPageSize)
+	}
+	return nil
+}
+
+// TableRowPageResult 表格行分页结果
+type TableRowPageResult struct {
+	Records []model.TableArticleRow `json:"records"`
+	Total   int64                   `json:"total"`
+	Size    int                     `json:"size"`
+	Current int                     `json:"current"`
+}
+
+// QueryTableRows 按条件过滤、排序并分页查询表格行（需要 viewer）
+// 行数据保存为 JSON，过滤与排序在服务端逐行计算；不排序时只保留当前页的行。
+func (s *Service) QueryTableRows(ctx context.Context, articleID uint, q *TableRowQuery) (*TableRowPageResult, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	table, schema, err := s.queryableTable(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := combineRowFilters(table, schema, q.Filter, q.IgnoreSavedFilters)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range q.Sort {
+		if st.Field == "" {
+			return nil, ErrBadRequest.WithMsg("排序字段不能为空")
+		}
+		if _, ok := schema.Column(st.Field); !ok && len(schema.Columns) > 0 {
+			return nil, ErrBadRequest.WithMsgf("排序列不存在: %s", st.Field)
+		}
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	var total int64
+	var matched []model.TableArticleRow
+	err = s.eachTableRow(ctx, table, func(row model.TableArticleRow) error {
+		if filter != nil && !filter.Match(row.RowData) {
+			return nil
+		}
+		total++
+		// 不排序时命中顺序即结果顺序，只需保留当前页
+		if len(q.Sort) > 0 || (total > int64(offset) && len(matched) < q.PageSize) {
+			matched = append(matched, row)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "查询表格行失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, err
+	}
+
+	if len(q.Sort) > 0 {
+		sortTableRows(matched, q.Sort)
+		matched = matched[min(offset, len(matched)):min(offset+q.PageSize, len(matched))]
+	}
+
+	return &TableRowPageResult{
+		Records: matched,
+		Total:   total,
+		Size:    q.PageSize,
+		Current: q.Page,
+	}, nil
+}
+
+// UpdateTableFilters 保存表格的过滤条件（需要 editor）
+func (s *Service) UpdateTableFilters(ctx context.Context, articleID uint, filters []RowFilter) error {
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return err
+	}
+	table, err := s.findTable(ctx, articleID)
+	if err != nil {
+		return err
+	}
+	schema := readSchema(table)
+	for i := range filters {
+		if err := filters[i].Validate(schema); err != nil {
+			return ErrBadRequest.WithMsgf("过滤条件无效: %v", err)
+		}
+	}
+
+	saved, err := encodeSavedFilters(filters)
+	if err != nil {
+		return ErrBadRequest.WithMsgf("过滤条件无效: %v", err)
+	}
+	// 过滤条件属于视图设置，不参与结构版本控制
+	if err := s.tableRepo.UpdateFilters(ctx, articleID, saved); err != nil {
+		s.logger.ErrorCtx(ctx, "保存表格过滤条件失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+// queryableTable 获取表格及其列结构（需要 viewer）
+func (s *Service) queryableTable(ctx context.Context, articleID uint) (*model.TableArticle, *TableSchema, error) {
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleViewer); err != nil {
+		return nil, nil, err
+	}
+	table, err := s.findTable(ctx, articleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, readSchema(table), nil
+}
+
+// readSchema 解析表格结构用于读取；结构无法解析时返回空结构（不校验、不计算公式）
+func readSchema(table *model.TableArticle) *TableSchema {
+	schema, err := ParseTableSchema(table.Structure)
+	if err != nil {
+		return &TableSchema{}
+	}
+	return schema
+}
+
+// combineRowFilters 合并保存的过滤条件与临时条件（AND），没有任何条件时返回 nil
+func combineRowFilters(table *model.TableArticle, schema *TableSchema, adhoc *RowFilter, ignoreSaved bool) (*RowFilter, error) {
+	var filters []RowFilter
+	if !ignoreSaved {
+		saved, err := parseSavedFilters(table.Filters)
+		if err != nil {
+			return nil, ErrBadRequest.WithMsgf("已保存的过滤条件无效: %v", err)
+		}
+		filters = append(filters, saved...)
+	}
+	if adhoc != nil {
+		if err := adhoc.Validate(schema); err != nil {
+			return nil, ErrBadRequest.WithMsgf("过滤条件无效: %v", err)
+		}
+		filters = append(filters, *adhoc)
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	return &RowFilter{And: filters}, nil
+}
+
+// findTable 获取表格结构记录
+func (s *Service) findTable(ctx context.Context, articleID uint) (*model.TableArticle, error) {
+	table, err := s.tableRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("表格不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return table, nil
+}
+
+// parseSavedFilters 将 TableArticle.Filters 解析为过滤表达式（不校验列名，结构调整后旧条件仍可用）
+func parseSavedFilters(saved model.JSONArray) ([]RowFilter, error) {
+	if len(saved) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(saved)
+	if err != nil {
+		return nil, err
+	}
+	var filters []RowFilter
+	if err := json.Unmarshal(b, &filters); err != nil {
+		return nil, err
+	}
+	for i := range filters {
+		if err := filters[i].Validate(nil); err != nil {
+			return nil, err
+		}
+	}
+	return filters, nil
+}
+
+func encodeSavedFilters(filters []RowFilter) (model.JSONArray, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	var saved model.JSONArray
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// sortTableRows 按多列稳定排序；空值始终排在最后，相同时保持原行顺序
+func sortTableRows(rows []model.TableArticleRow, sorts []RowSort) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, st := range sorts {
+			a, b := rows[i].RowData[st.Field], rows[j].RowData[st.Field]
+			aEmpty, bEmpty := isEmptyCell(a), isEmptyCell(b)
+			switch {
+			case aEmpty && bEmpty:
+				continue
+			case aEmpty:
+				return false
+			case bEmpty:
+				return true
+			}
+			c, ok := compareCells(a, b)
+			if !ok {
+				c = strings.Compare(formatCellText(a), formatCellText(b))
+			}
+			if c == 0 {
+				continue
+			}
+			if st.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,288 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 行级操作：在不重写整张表的前提下增删改、移动单行。
+// RowIndex 始终保持 0..n-1 连续，位置变化只平移受影响区间内的行。
+// 每个写操作都在事务开始时递增 DataVersion，使整表保存的 expectedVersion 能感知行级修改，
+// 同时借助该行的写锁串行化同一表格上的并发行操作。
+
+// InsertTableRow 在 position 处插入一行，原位置及之后的行顺延；position 越界（<0 或超过行数）时追加到末尾
+func (s *Service) InsertTableRow(ctx context.Context, articleID uint, position int, data map[string]interface{}) (*model.TableArticleRow, error) {
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return nil, err
+	}
+	schema, err := s.tableSchema(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareTableRows(schema, []map[string]interface{}{data}); err != nil {
+		return nil, err
+	}
+
+	var row *model.TableArticleRow
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		if _, err := bumpTableDataVersion(ctx, repos, articleID, 0); err != nil {
+			return err
+		}
+		count, err := repos.TableRow.Count(ctx, articleID)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		if position < 0 || position > int(count) {
+			position = int(count)
+		}
+		if position < int(count) {
+			if err := repos.TableRow.ShiftRowIndexes(ctx, articleID, position, -1, 1); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+
+		now := time.Now()
+		row = &model.TableArticleRow{
+			ArticleID: articleID,
+			RowData:   model.JSONMap(data),
+			RowIndex:  &position,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if row.RowData == nil {
+			row.RowData = model.JSONMap{}
+		}
+		if err := repos.TableRow.Create(ctx, row); err != nil {
+			s.logger.ErrorCtx(ctx, "插入表格行失败", zap.Uint("article_id", articleID), zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexArticle(ctx, articleID)
+	return row, nil
+}
+
+// UpdateTableRow 整行替换行数据，保留行 ID、位置与创建时间
+func (s *Service) UpdateTableRow(ctx context.Context, articleID, rowID uint, data map[string]interface{}) (*model.TableArticleRow, error) {
+	return s.updateTableRow(ctx, articleID, rowID, func(row *model.TableArticleRow) {
+		row.RowData = model.JSONMap(data)
+		if row.RowData == nil {
+			row.RowData = model.JSONMap{}
+		}
+	})
+}
+
+// PatchTableRow 局部修改行数据：patch 中的键覆盖原值，值为 nil 的键从行中删除
+func (s *Service) PatchTableRow(ctx context.Context, articleID, rowID uint, patch map[string]interface{}) (*model.TableArticleRow, error) {
+	return s.updateTableRow(ctx, articleID, rowID, func(row *model.TableArticleRow) {
+		if row.RowData == nil {
+			row.RowData = model.JSONMap{}
+		}
+		for field, value := range patch {
+			if value == nil {
+				delete(row.RowData, field)
+				continue
+			}
+			row.RowData[field] = value
+		}
+	})
+}
+
+func (s *Service) updateTableRow(ctx context.Context, articleID, rowID uint, apply func(row *model.TableArticleRow)) (*model.TableArticleRow, error) {
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return nil, err
+	}
+	schema, err := s.tableSchema(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var row *model.TableArticleRow
+	err = s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		if _, err := bumpTableDataVersion(ctx, repos, articleID, 0); err != nil {
+			return err
+		}
+		var err error
+		row, err = findTableRow(ctx, repos, articleID, rowID)
+		if err != nil {
+			return err
+		}
+
+		before := maps.Clone(row.RowData)
+		apply(row)
+		if err := prepareTableRowUpdate(schema, before, row.RowData); err != nil {
+			return err
+		}
+		row.UpdatedAt = time.Now()
+		if err := repos.TableRow.Update(ctx, row); err != nil {
+			s.logger.ErrorCtx(ctx, "更新表格行失败", zap.Uint("article_id", articleID), zap.Uint("row_id", rowID), zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexArticle(ctx, articleID)
+	return row, nil
+}
+
+// DeleteTableRows 删除指定行，之后的行依次前移
+func (s *Service) DeleteTableRows(ctx context.Context, articleID uint, rowIDs []uint) error {
+	rowIDs = uniqueIDs(rowIDs)
+	if len(rowIDs) == 0 {
+		return nil
+	}
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return err
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return err
+	}
+
+	err := s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		if _, err := bumpTableDataVersion(ctx, repos, articleID, 0); err != nil {
+			return err
+		}
+		rows, err := repos.TableRow.FindByIDs(ctx, articleID, rowIDs)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		if len(rows) != len(rowIDs) {
+			return ErrNotFound.WithMsg("部分行不存在")
+		}
+
+		if err := repos.TableRow.DeleteByIDs(ctx, articleID, rowIDs); err != nil {
+			s.logger.ErrorCtx(ctx, "删除表格行失败", zap.Uint("article_id", articleID), zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		// 从后往前逐个收拢空位，每次只平移被删行之后的区间
+		var indexes []int
+		for _, row := range rows {
+			if row.RowIndex != nil {
+				indexes = append(indexes, *row.RowIndex)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+		for _, idx := range indexes {
+			if err := repos.TableRow.ShiftRowIndexes(ctx, articleID, idx+1, -1, -1); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.indexArticle(ctx, articleID)
+	return nil
+}
+
+// MoveTableRow 将行移动到 toIndex，两者之间的行相应平移；toIndex 越界时移到末尾
+func (s *Service) MoveTableRow(ctx context.Context, articleID, rowID uint, toIndex int) error {
+	if _, err := s.getTableArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return err
+	}
+	if err := s.checkEditLock(ctx, articleID); err != nil {
+		return err
+	}
+
+	return s.inEditTx(ctx, articleID, func(ctx context.Context, repos *Repositories) error {
+		if _, err := bumpTableDataVersion(ctx, repos, articleID, 0); err != nil {
+			return err
+		}
+		row, err := findTableRow(ctx, repos, articleID, rowID)
+		if err != nil {
+			return err
+		}
+		count, err := repos.TableRow.Count(ctx, articleID)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		if toIndex < 0 || toIndex >= int(count) {
+			toIndex = int(count) - 1
+		}
+		if row.RowIndex == nil {
+			return ErrBadRequest.WithMsg("行缺少位置信息，请先整表保存")
+		}
+
+		from := *row.RowIndex
+		switch {
+		case toIndex == from:
+			return nil
+		case toIndex > from:
+			err = repos.TableRow.ShiftRowIndexes(ctx, articleID, from+1, toIndex, -1)
+		default:
+			err = repos.TableRow.ShiftRowIndexes(ctx, articleID, toIndex, from-1, 1)
+		}
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		row.RowIndex = &toIndex
+		if err := repos.TableRow.Update(ctx, row); err != nil {
+			s.logger.ErrorCtx(ctx, "移动表格行失败", zap.Uint("article_id", articleID), zap.Uint("row_id", rowID), zap.Error(err))
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+}
+
+// getTableArticleFor 获取表格文章并校验权限与类型
+func (s *Service) getTableArticleFor(ctx context.Context, articleID uint, minRole string) (*model.Article, error) {
+	article, err := s.getArticleFor(ctx, articleID, minRole)
+	if err != nil {
+		return nil, err
+	}
+	if article.ArticleType != model.ArticleTypeTable {
+		return nil, ErrBadRequest.WithMsg("该文章不是表格类型")
+	}
+	return article, nil
+}
+
+// findTableRow 查找属于指定文章的行
+func findTableRow(ctx context.Context, repos *Repositories, articleID, rowID uint) (*model.TableArticleRow, error) {
+	row, err := repos.TableRow.FindByID(ctx, rowID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("行不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if row.ArticleID != articleID {
+		return nil, ErrNotFound.WithMsg("行不存在")
+	}
+	return row, nil
+}
+
+// bumpTableDataVersion 递增表格行数据版本，expectedVersion > 0 时以其为条件；返回递增后的版本
+func bumpTableDataVersion(ctx context.Context, repos *Repositories, articleID uint, expectedVersion int) (int, error) {
+	version, err := repos.Table.BumpDataVersion(ctx, articleID, expectedVersion)
+	if err == nil {
+		return version, nil
+	}
+	if errors.Is(err, ErrStaleVersion) && expectedVersion <= 0 {
+		return 0, ErrNotFound.WithMsg("表格不存在")
+	}
+	return 0, versionedWriteError(err)
+}
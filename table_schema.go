@@ -0,0 +1,671 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"gorm.io/gorm"
+)
+
+// maxCellErrors 单次校验最多收集的单元格错误数
+const maxCellErrors = 100
+
+// ColumnDef 表格列定义（由 Structure 中的一项解析而来）
+//
+// Structure 列定义支持的键：
+//
+//	field    列标识（必填，表内唯一）
+//	title    列标题
+//	type     列类型，缺省为 text
+//	required 是否必填
+//	min/max  number、integer 为取值范围；text、url、email 为字符数；multi_select 为选项个数
+//	pattern  正则约束，适用于 text、url、email
+//	options  可选值，single_select、multi_select 必填；元素为字符串或 {"value": ...}
+//	formula  公式表达式，formula 列必填（语法见 formula.go）
+type ColumnDef struct {
+	Field    string
+	Title    string
+	Type     string
+	Required bool
+	Min      *float64
+	Max      *float64
+	Pattern  string
+	Options  []string
+	Formula  string
+
+	pattern *regexp.Regexp
+	options map[string]bool
+	formula *formulaExpr
+}
+
+// IsComputed 是否为公式列（值在读取时计算，不接受写入）
+func (c *ColumnDef) IsComputed() bool {
+	return c.Type == model.ColumnTypeFormula
+}
+
+// TableSchema 表格列结构
+type TableSchema struct {
+	Columns []ColumnDef
+	byField map[string]int
+	// formulaOrder 公式列按依赖排好的计算顺序（Columns 下标）
+	formulaOrder []int
+}
+
+// Column 按 field 查找列定义
+func (s *TableSchema) Column(field string) (*ColumnDef, bool) {
+	i, ok := s.byField[field]
+	if !ok {
+		return nil, false
+	}
+	return &s.Columns[i], true
+}
+
+// ParseTableSchema 解析并校验表格结构定义
+func ParseTableSchema(structure []map[string]interface{}) (*TableSchema, error) {
+	schema := &TableSchema{
+		Columns: make([]ColumnDef, 0, len(structure)),
+		byField: make(map[string]int, len(structure)),
+	}
+	for i, raw := range structure {
+		col, err := parseColumnDef(raw)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 列: %w", i+1, err)
+		}
+		if _, dup := schema.byField[col.Field]; dup {
+			return nil, fmt.Errorf("第 %d 列: field 重复: %s", i+1, col.Field)
+		}
+		schema.byField[col.Field] = len(schema.Columns)
+		schema.Columns = append(schema.Columns, *col)
+	}
+	if err := schema.resolveFormulas(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// resolveFormulas 校验公式引用的列并按依赖拓扑排序，存在循环引用时报错
+func (s *TableSchema) resolveFormulas() error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(s.Columns))
+
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		col := &s.Columns[i]
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("公式列存在循环引用: %s", strings.Join(append(path, col.Field), " -> "))
+		}
+		state[i] = visiting
+		for _, ref := range col.formula.refs {
+			j, ok := s.byField[ref]
+			if !ok {
+				return fmt.Errorf("列 %s 的公式引用了不存在的列: %s", col.Field, ref)
+			}
+			if s.Columns[j].IsComputed() {
+				if err := visit(j, append(path, col.Field)); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = done
+		s.formulaOrder = append(s.formulaOrder, i)
+		return nil
+	}
+
+	for i := range s.Columns {
+		if s.Columns[i].IsComputed() {
+			if err := visit(i, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyFormulas 按依赖顺序计算公式列并写回行数据；计算出错的单元格为 nil
+func (s *TableSchema) ApplyFormulas(row map[string]interface{}) {
+	for _, i := range s.formulaOrder {
+		col := &s.Columns[i]
+		v, err := col.formula.eval(row)
+		if err != nil {
+			v = nil
+		}
+		row[col.Field] = v
+	}
+}
+
+// HasFormulas 是否包含公式列
+func (s *TableSchema) HasFormulas() bool {
+	return len(s.formulaOrder) > 0
+}
+
+func parseColumnDef(raw map[string]interface{}) (*ColumnDef, error) {
+	col := &ColumnDef{}
+	col.Field, _ = raw["field"].(string)
+	if strings.TrimSpace(col.Field) == "" {
+		return nil, errors.New("缺少 field")
+	}
+	col.Title, _ = raw["title"].(string)
+
+	col.Type, _ = raw["type"].(string)
+	if col.Type == "" {
+		col.Type = model.ColumnTypeText
+	}
+	if !isColumnType(col.Type) {
+		return nil, fmt.Errorf("列 %s 的类型不支持: %s", col.Field, col.Type)
+	}
+
+	if col.Type == model.ColumnTypeFormula {
+		return parseFormulaColumn(col, raw)
+	}
+
+	if v, ok := raw["required"]; ok && v != nil {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("列 %s 的 required 必须是布尔值", col.Field)
+		}
+		col.Required = b
+	}
+
+	var err error
+	if col.Min, err = parseColumnBound(raw, col.Field, "min"); err != nil {
+		return nil, err
+	}
+	if col.Max, err = parseColumnBound(raw, col.Field, "max"); err != nil {
+		return nil, err
+	}
+	if col.Min != nil || col.Max != nil {
+		switch col.Type {
+		case model.ColumnTypeNumber, model.ColumnTypeInteger, model.ColumnTypeText,
+			model.ColumnTypeURL, model.ColumnTypeEmail, model.ColumnTypeMultiSelect:
+		default:
+			return nil, fmt.Errorf("列 %s 的类型 %s 不支持 min/max", col.Field, col.Type)
+		}
+		if col.Min != nil && col.Max != nil && *col.Min > *col.Max {
+			return nil, fmt.Errorf("列 %s 的 min 大于 max", col.Field)
+		}
+	}
+
+	if v, ok := raw["pattern"]; ok && v != nil {
+		pattern, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("列 %s 的 pattern 必须是字符串", col.Field)
+		}
+		switch col.Type {
+		case model.ColumnTypeText, model.ColumnTypeURL, model.ColumnTypeEmail:
+		default:
+			return nil, fmt.Errorf("列 %s 的类型 %s 不支持 pattern", col.Field, col.Type)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("列 %s 的 pattern 无效: %w", col.Field, err)
+		}
+		col.Pattern, col.pattern = pattern, re
+	}
+
+	isSelect := col.Type == model.ColumnTypeSingleSelect || col.Type == model.ColumnTypeMultiSelect
+	options, err := parseColumnOptions(raw["options"])
+	if err != nil {
+		return nil, fmt.Errorf("列 %s 的 %w", col.Field, err)
+	}
+	switch {
+	case isSelect && len(options) == 0:
+		return nil, fmt.Errorf("列 %s 缺少 options", col.Field)
+	case !isSelect && len(options) > 0:
+		return nil, fmt.Errorf("列 %s 的类型 %s 不支持 options", col.Field, col.Type)
+	}
+	col.Options = options
+	col.options = make(map[string]bool, len(options))
+	for _, opt := range options {
+		col.options[opt] = true
+	}
+	return col, nil
+}
+
+// parseFormulaColumn 解析公式列；公式列不支持 required、min/max、pattern、options 等写入约束
+func parseFormulaColumn(col *ColumnDef, raw map[string]interface{}) (*ColumnDef, error) {
+	for _, key := range []string{"required", "min", "max", "pattern", "options"} {
+		if v, ok := raw[key]; ok && v != nil && v != false {
+			return nil, fmt.Errorf("公式列 %s 不支持 %s", col.Field, key)
+		}
+	}
+	src, _ := raw["formula"].(string)
+	expr, err := parseFormula(src)
+	if err != nil {
+		return nil, fmt.Errorf("列 %s 的公式无效: %w", col.Field, err)
+	}
+	col.Formula, col.formula = src, expr
+	return col, nil
+}
+
+func parseColumnBound(raw map[string]interface{}, field, key string) (*float64, error) {
+	v, ok := raw[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	n, ok := numericValue(v)
+	if !ok {
+		return nil, fmt.Errorf("列 %s 的 %s 必须是数字", field, key)
+	}
+	return &n, nil
+}
+
+func parseColumnOptions(v interface{}) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	items, ok := stringList(v)
+	if !ok {
+		raw, isArray := v.([]interface{})
+		if !isArray {
+			return nil, errors.New("options 必须是数组")
+		}
+		items = make([]string, len(raw))
+		for i, item := range raw {
+			switch opt := item.(type) {
+			case string:
+				items[i] = opt
+			case map[string]interface{}:
+				items[i], _ = opt["value"].(string)
+			}
+		}
+	}
+	options := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, value := range items {
+		if value == "" {
+			return nil, errors.New("options 元素必须是非空字符串或包含 value 的对象")
+		}
+		if !seen[value] {
+			seen[value] = true
+			options = append(options, value)
+		}
+	}
+	return options, nil
+}
+
+func isColumnType(t string) bool {
+	switch t {
+	case model.ColumnTypeText, model.ColumnTypeNumber, model.ColumnTypeInteger, model.ColumnTypeBoolean,
+		model.ColumnTypeDate, model.ColumnTypeDatetime, model.ColumnTypeSingleSelect, model.ColumnTypeMultiSelect,
+		model.ColumnTypeURL, model.ColumnTypeEmail, model.ColumnTypeFormula:
+		return true
+	}
+	return false
+}
+
+// ==================== 行校验 ====================
+
+// CellError 单元格校验错误
+type CellError struct {
+	Row     int    `json:"row"` // 行在本次写入数据中的序号（从 0 开始）
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RowValidationError 行数据校验失败，包含逐个单元格的错误（最多 maxCellErrors 条）
+type RowValidationError struct {
+	Errors    []CellError `json:"errors"`
+	Truncated bool        `json:"truncated"`
+}
+
+func (e *RowValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, ce := range e.Errors {
+		parts[i] = fmt.Sprintf("第 %d 行 %s: %s", ce.Row+1, ce.Field, ce.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *RowValidationError) add(row int, field, message string) bool {
+	if len(e.Errors) >= maxCellErrors {
+		e.Truncated = true
+		return false
+	}
+	e.Errors = append(e.Errors, CellError{Row: row, Field: field, Message: message})
+	return true
+}
+
+// ValidateRows 按列结构校验行数据；无列定义的表格（旧数据）不做校验
+func (s *TableSchema) ValidateRows(rows []map[string]interface{}) *RowValidationError {
+	if len(s.Columns) == 0 {
+		return nil
+	}
+	verr := &RowValidationError{}
+	for i, row := range rows {
+		if !s.validateRow(verr, i, row) {
+			break
+		}
+	}
+	if len(verr.Errors) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// ValidateRowChanges 只校验 after 相对 before 发生变化的单元格
+// 结构变更不会回头校验已有行，整行校验会让与新结构不符的旧值阻塞对其他单元格的修改；
+// 删除未定义的列不报错，删除必填列按必填校验。
+func (s *TableSchema) ValidateRowChanges(before, after map[string]interface{}) *RowValidationError {
+	if len(s.Columns) == 0 {
+		return nil
+	}
+	var changed []string
+	for field, v := range after {
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, v) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+
+	verr := &RowValidationError{}
+	for _, field := range changed {
+		col, ok := s.Column(field)
+		_, present := after[field]
+		var msg string
+		switch {
+		case !ok && present:
+			msg = "未定义的列"
+		case ok && !col.IsComputed():
+			msg = col.validate(after[field])
+		}
+		if msg != "" && !verr.add(0, field, msg) {
+			break
+		}
+	}
+	if len(verr.Errors) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// validateRow 校验单行，错误数达到上限时返回 false
+func (s *TableSchema) validateRow(verr *RowValidationError, index int, row map[string]interface{}) bool {
+	var unknown []string
+	for field := range row {
+		if _, ok := s.byField[field]; !ok {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	for _, field := range unknown {
+		if !verr.add(index, field, "未定义的列") {
+			return false
+		}
+	}
+	for i := range s.Columns {
+		col := &s.Columns[i]
+		if col.IsComputed() {
+			continue
+		}
+		if msg := col.validate(row[col.Field]); msg != "" {
+			if !verr.add(index, col.Field, msg) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validate 校验单元格值，返回错误描述（空串表示通过）
+func (c *ColumnDef) validate(v interface{}) string {
+	if isEmptyCell(v) {
+		if c.Required {
+			return "必填"
+		}
+		return ""
+	}
+
+	switch c.Type {
+	case model.ColumnTypeText, model.ColumnTypeURL, model.ColumnTypeEmail:
+		s, ok := v.(string)
+		if !ok {
+			return "必须是字符串"
+		}
+		if msg := c.checkRange(float64(utf8.RuneCountInString(s)), "长度"); msg != "" {
+			return msg
+		}
+		if c.pattern != nil && !c.pattern.MatchString(s) {
+			return "格式不匹配: " + c.Pattern
+		}
+		if c.Type == model.ColumnTypeURL && !isValidURL(s) {
+			return "不是有效的 URL"
+		}
+		if c.Type == model.ColumnTypeEmail && !isValidEmail(s) {
+			return "不是有效的邮箱地址"
+		}
+
+	case model.ColumnTypeNumber, model.ColumnTypeInteger:
+		n, ok := numericValue(v)
+		if !ok {
+			return "必须是数字"
+		}
+		if c.Type == model.ColumnTypeInteger && n != math.Trunc(n) {
+			return "必须是整数"
+		}
+		return c.checkRange(n, "值")
+
+	case model.ColumnTypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return "必须是布尔值"
+		}
+
+	case model.ColumnTypeDate, model.ColumnTypeDatetime:
+		s, ok := v.(string)
+		if !ok {
+			return "必须是字符串"
+		}
+		layout := time.DateOnly
+		if c.Type == model.ColumnTypeDatetime {
+			layout = time.RFC3339
+		}
+		if _, err := time.Parse(layout, s); err != nil {
+			return "日期格式应为 " + layout
+		}
+
+	case model.ColumnTypeSingleSelect:
+		s, ok := v.(string)
+		if !ok {
+			return "必须是字符串"
+		}
+		if !c.options[s] {
+			return "不在可选值中: " + s
+		}
+
+	case model.ColumnTypeMultiSelect:
+		items, ok := stringList(v)
+		if !ok {
+			return "必须是字符串数组"
+		}
+		seen := make(map[string]bool, len(items))
+		for _, s := range items {
+			if !c.options[s] {
+				return "不在可选值中: " + s
+			}
+			if seen[s] {
+				return "选项重复: " + s
+			}
+			seen[s] = true
+		}
+		return c.checkRange(float64(len(items)), "选项个数")
+	}
+	return ""
+}
+
+func (c *ColumnDef) checkRange(n float64, what string) string {
+	if c.Min != nil && n < *c.Min {
+		return fmt.Sprintf("%s不能小于 %v", what, *c.Min)
+	}
+	if c.Max != nil && n > *c.Max {
+		return fmt.Sprintf("%s不能大于 %v", what, *c.Max)
+	}
+	return ""
+}
+
+// isEmptyCell nil、空字符串与空数组视为未填写
+func isEmptyCell(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []interface{}:
+		return len(val) == 0
+	case []string:
+		return len(val) == 0
+	}
+	return false
+}
+
+// stringList 读取多选值：JSON 解码得到 []interface{}，Go 调用方可能直接传 []string
+func stringList(v interface{}) ([]string, bool) {
+	switch val := v.(type) {
+	case []string:
+		return val, true
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items[i] = s
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// numericValue 将 JSON 解码或导入得到的数值统一为 float64，拒绝 NaN 与 Inf
+func numericValue(v interface{}) (float64, bool) {
+	var f float64
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case float32:
+		f = float64(n)
+	case int:
+		f = float64(n)
+	case int8:
+		f = float64(n)
+	case int16:
+		f = float64(n)
+	case int32:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case uint:
+		f = float64(n)
+	case uint8:
+		f = float64(n)
+	case uint16:
+		f = float64(n)
+	case uint32:
+		f = float64(n)
+	case uint64:
+		f = float64(n)
+	case json.Number:
+		var err error
+		if f, err = n.Float64(); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+func isValidURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isValidEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// ==================== Service 集成 ====================
+
+// parseStructure 解析结构定义，失败时返回 ErrBadRequest
+func parseStructure(structure []map[string]interface{}) (*TableSchema, error) {
+	schema, err := ParseTableSchema(structure)
+	if err != nil {
+		return nil, ErrBadRequest.WithMsgf("表格结构无效: %v", err)
+	}
+	return schema, nil
+}
+
+// tableSchema 读取并解析表格当前结构
+func (s *Service) tableSchema(ctx context.Context, articleID uint) (*TableSchema, error) {
+	table, err := s.tableRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("表格不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return parseStructure(table.Structure)
+}
+
+// prepareTableRows 去掉公式列的值（只在读取时计算）并校验待写入的行，失败时返回带逐格错误的 ErrBadRequest
+func prepareTableRows(schema *TableSchema, rows []map[string]interface{}) error {
+	for _, i := range schema.formulaOrder {
+		for _, row := range rows {
+			delete(row, schema.Columns[i].Field)
+		}
+	}
+
+	return rowValidationError(schema.ValidateRows(rows))
+}
+
+// prepareTableRowUpdate 修改单行时去掉公式列的值，只校验发生变化的单元格
+func prepareTableRowUpdate(schema *TableSchema, before, after map[string]interface{}) error {
+	for _, i := range schema.formulaOrder {
+		delete(after, schema.Columns[i].Field)
+	}
+	return rowValidationError(schema.ValidateRowChanges(before, after))
+}
+
+// rowValidationError 将校验结果转换为带逐格错误的 ErrBadRequest，校验通过时返回 nil
+func rowValidationError(verr *RowValidationError) error {
+	if verr == nil {
+		return nil
+	}
+	first := verr.Errors[0]
+	msg := fmt.Sprintf("行数据校验失败: 第 %d 行 %s %s", first.Row+1, first.Field, first.Message)
+	if n := len(verr.Errors); n > 1 || verr.Truncated {
+		msg += fmt.Sprintf("（共 %d 处错误", n)
+		if verr.Truncated {
+			msg += "，已截断"
+		}
+		msg += "）"
+	}
+	return ErrBadRequest.WithMsg(msg).Wrap(verr)
+}
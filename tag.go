@@ -0,0 +1,346 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 标签 ====================
+
+// maxTagNameLength 标签名最大长度（字符）
+const maxTagNameLength = 50
+
+// WithTagRepository 注入标签仓储，启用文章标签
+func WithTagRepository(r TagRepository) ServiceOption {
+	return func(s *Service) {
+		s.tagRepo = r
+	}
+}
+
+// CreateTagInput 创建标签输入
+type CreateTagInput struct {
+	OwnerID   uint
+	OwnerType string
+	Name      string
+	Color     string
+}
+
+// CreateTag 创建标签
+func (s *Service) CreateTag(ctx context.Context, input *CreateTagInput) (*model.Tag, error) {
+	if err := s.requireTagRepo(); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwnerScope(ctx, input.OwnerType, input.OwnerID); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.tagRepo.FindByNames(ctx, input.OwnerType, input.OwnerID, []string{name})
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if len(existing) > 0 {
+		return nil, ErrBadRequest.WithMsgf("标签已存在: %s", name)
+	}
+
+	tag := &model.Tag{
+		OwnerID:   input.OwnerID,
+		OwnerType: input.OwnerType,
+		Name:      name,
+		Color:     input.Color,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		s.logger.ErrorCtx(ctx, "创建标签失败", zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return tag, nil
+}
+
+// ListTags 查询所有者的全部标签（按名称排序）
+func (s *Service) ListTags(ctx context.Context, ownerType string, ownerID uint) ([]model.Tag, error) {
+	if err := s.requireTagRepo(); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwnerScope(ctx, ownerType, ownerID); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.FindByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return tags, nil
+}
+
+// RenameTag 重命名标签；新名称已被同一所有者的其他标签占用时请使用 MergeTags
+func (s *Service) RenameTag(ctx context.Context, tagID uint, name string) (*model.Tag, error) {
+	tag, err := s.getOwnedTag(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == tag.Name {
+		return tag, nil
+	}
+
+	existing, err := s.tagRepo.FindByNames(ctx, tag.OwnerType, tag.OwnerID, []string{name})
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if len(existing) > 0 {
+		return nil, ErrBadRequest.WithMsgf("标签已存在: %s，请使用合并", name)
+	}
+
+	tag.Name = name
+	tag.UpdatedAt = time.Now()
+	if err := s.tagRepo.Update(ctx, tag); err != nil {
+		s.logger.ErrorCtx(ctx, "重命名标签失败", zap.Uint("tag_id", tagID), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return tag, nil
+}
+
+// MergeTags 将若干标签合并到目标标签：文章关联转移到目标标签后删除源标签
+// 源标签与目标标签必须属于同一所有者。
+func (s *Service) MergeTags(ctx context.Context, sourceIDs []uint, targetID uint) error {
+	target, err := s.getOwnedTag(ctx, targetID)
+	if err != nil {
+		return err
+	}
+
+	sourceIDs = uniqueIDs(sourceIDs)
+	sources, err := s.tagRepo.FindByIDs(ctx, sourceIDs)
+	if err != nil {
+		return ErrDatabaseError.Wrap(err)
+	}
+	if len(sources) != len(sourceIDs) {
+		return ErrNotFound.WithMsg("标签不存在")
+	}
+	for _, src := range sources {
+		if src.ID == target.ID {
+			return ErrBadRequest.WithMsg("不能将标签合并到自身")
+		}
+		if src.OwnerType != target.OwnerType || src.OwnerID != target.OwnerID {
+			return ErrBadRequest.WithMsg("只能合并同一所有者的标签")
+		}
+	}
+
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		for _, src := range sources {
+			if err := repos.Tag.MoveArticles(ctx, src.ID, target.ID); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+			if err := repos.Tag.Delete(ctx, src.ID); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "合并标签失败", zap.Uint("target_id", targetID), zap.Error(err))
+		return err
+	}
+
+	s.logger.InfoCtx(ctx, "标签合并成功", zap.Uint("target_id", targetID), zap.Int("merged", len(sources)))
+	return nil
+}
+
+// DeleteTag 删除标签及其全部文章关联
+func (s *Service) DeleteTag(ctx context.Context, tagID uint) error {
+	if _, err := s.getOwnedTag(ctx, tagID); err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		if err := repos.Tag.DeleteArticleTagsByTagID(ctx, tagID); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		if err := repos.Tag.Delete(ctx, tagID); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+}
+
+// AddArticleTags 按名称为文章添加标签，标签不存在时在文章所有者下自动创建
+func (s *Service) AddArticleTags(ctx context.Context, articleID uint, names []string) ([]model.Tag, error) {
+	if err := s.requireTagRepo(); err != nil {
+		return nil, err
+	}
+	article, err := s.getArticleFor(ctx, articleID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized []string
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		name, err := normalizeTagName(n)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) == 0 {
+		return s.ListArticleTags(ctx, articleID)
+	}
+
+	err = s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		existing, err := repos.Tag.FindByNames(ctx, article.OwnerType, article.OwnerID, normalized)
+		if err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		byName := make(map[string]uint, len(existing))
+		for _, t := range existing {
+			byName[t.Name] = t.ID
+		}
+
+		tagIDs := make([]uint, 0, len(normalized))
+		for _, name := range normalized {
+			if id, ok := byName[name]; ok {
+				tagIDs = append(tagIDs, id)
+				continue
+			}
+			tag := &model.Tag{
+				OwnerID:   article.OwnerID,
+				OwnerType: article.OwnerType,
+				Name:      name,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			if err := repos.Tag.Create(ctx, tag); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+			tagIDs = append(tagIDs, tag.ID)
+		}
+		if err := repos.Tag.AddToArticle(ctx, articleID, tagIDs); err != nil {
+			return ErrDatabaseError.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "添加文章标签失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return nil, err
+	}
+
+	return s.ListArticleTags(ctx, articleID)
+}
+
+// RemoveArticleTags 移除文章上的标签（标签本身保留）
+func (s *Service) RemoveArticleTags(ctx context.Context, articleID uint, tagIDs []uint) error {
+	if err := s.requireTagRepo(); err != nil {
+		return err
+	}
+	if _, err := s.getArticleFor(ctx, articleID, model.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.RemoveFromArticle(ctx, articleID, tagIDs); err != nil {
+		s.logger.ErrorCtx(ctx, "移除文章标签失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return ErrDatabaseError.Wrap(err)
+	}
+	return nil
+}
+
+// ListArticleTags 查询文章的全部标签
+func (s *Service) ListArticleTags(ctx context.Context, articleID uint) ([]model.Tag, error) {
+	if err := s.requireTagRepo(); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetArticle(ctx, articleID); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.FindByArticleID(ctx, articleID)
+	if err != nil {
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	return tags, nil
+}
+
+// TagCount 标签云条目
+type TagCount struct {
+	Tag   model.Tag `json:"tag"`
+	Count int64     `json:"count"`
+}
+
+// GetTagCloud 统计所有者各标签下的文章数（不含已删除文章），按数量倒序
+// 未被任何文章使用的标签也会返回，数量为 0。
+func (s *Service) GetTagCloud(ctx context.Context, ownerType string, ownerID uint) ([]TagCount, error) {
+	tags, err := s.ListTags(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.tagRepo.CountArticles(ctx, ownerType, ownerID)
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "统计标签文章数失败", zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	cloud := make([]TagCount, len(tags))
+	for i, t := range tags {
+		cloud[i] = TagCount{Tag: t, Count: counts[t.ID]}
+	}
+	sort.SliceStable(cloud, func(i, j int) bool {
+		return cloud[i].Count > cloud[j].Count
+	})
+	return cloud, nil
+}
+
+// getOwnedTag 获取标签并校验当前主体可以管理其所有者的标签
+func (s *Service) getOwnedTag(ctx context.Context, tagID uint) (*model.Tag, error) {
+	if err := s.requireTagRepo(); err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagRepo.FindByID(ctx, tagID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("标签不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if err := s.authorizeOwnerScope(ctx, tag.OwnerType, tag.OwnerID); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *Service) requireTagRepo() error {
+	if s.tagRepo == nil {
+		return ErrBadRequest.WithMsg("未启用标签")
+	}
+	return nil
+}
+
+// normalizeTagName 去除首尾空白并校验长度
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrBadRequest.WithMsg("标签名不能为空")
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", ErrBadRequest.WithMsgf("标签名不能超过 %d 个字符", maxTagNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,261 @@
+package article
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+)
+
+// 文本操作变换（OT）。
+// 一个操作从头到尾覆盖整篇文档：retain 跳过字符，insert 插入文本，delete 删除字符，长度按 Unicode 码点计算。
+// 操作以规范形式保存：相邻同类分量合并，同一位置的插入排在删除之前。
+
+var errTextOperationLength = errors.New("操作长度与文档不匹配")
+
+// maxTextOperationLength 操作要求的文档长度与应用后的文档长度上限（码点），防止客户端提交的长度溢出
+const maxTextOperationLength = 16 << 20
+
+// textOpBuilder 按规范形式逐个追加分量
+type textOpBuilder struct {
+	ops model.TextOperation
+}
+
+func (b *textOpBuilder) retain(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(b.ops) - 1; last >= 0 && b.ops[last].Retain > 0 {
+		b.ops[last].Retain += n
+		return
+	}
+	b.ops = append(b.ops, model.TextOpComponent{Retain: n})
+}
+
+func (b *textOpBuilder) insert(s string) {
+	if s == "" {
+		return
+	}
+	last := len(b.ops) - 1
+	if last >= 0 && b.ops[last].Insert != "" {
+		b.ops[last].Insert += s
+		return
+	}
+	if last >= 0 && b.ops[last].Delete > 0 {
+		// 插入与删除相邻时插入在前，保证同一编辑只有一种表示
+		if last > 0 && b.ops[last-1].Insert != "" {
+			b.ops[last-1].Insert += s
+			return
+		}
+		b.ops = append(b.ops, b.ops[last])
+		b.ops[last] = model.TextOpComponent{Insert: s}
+		return
+	}
+	b.ops = append(b.ops, model.TextOpComponent{Insert: s})
+}
+
+func (b *textOpBuilder) delete(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(b.ops) - 1; last >= 0 && b.ops[last].Delete > 0 {
+		b.ops[last].Delete += n
+		return
+	}
+	b.ops = append(b.ops, model.TextOpComponent{Delete: n})
+}
+
+// add 追加任意分量
+func (b *textOpBuilder) add(c model.TextOpComponent) {
+	switch {
+	case c.Retain > 0:
+		b.retain(c.Retain)
+	case c.Insert != "":
+		b.insert(c.Insert)
+	case c.Delete > 0:
+		b.delete(c.Delete)
+	}
+}
+
+func (b *textOpBuilder) operation() model.TextOperation {
+	if b.ops == nil {
+		return model.TextOperation{}
+	}
+	return b.ops
+}
+
+// normalizeTextOperation 校验并转换为规范形式
+func normalizeTextOperation(op model.TextOperation) (model.TextOperation, error) {
+	var b textOpBuilder
+	var base, target int
+	for i, c := range op {
+		set := 0
+		if c.Retain != 0 {
+			set++
+		}
+		if c.Insert != "" {
+			set++
+		}
+		if c.Delete != 0 {
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("第 %d 个分量必须且只能包含 retain、insert、delete 之一", i+1)
+		}
+		if c.Retain < 0 || c.Delete < 0 {
+			return nil, fmt.Errorf("第 %d 个分量长度不能为负数", i+1)
+		}
+		if !utf8.ValidString(c.Insert) {
+			return nil, fmt.Errorf("第 %d 个分量包含无效的 UTF-8 文本", i+1)
+		}
+		// 逐个分量累加并与上限比较，单个分量超限时也不会溢出
+		if c.Retain > maxTextOperationLength-max(base, target) ||
+			c.Delete > maxTextOperationLength-base ||
+			utf8.RuneCountInString(c.Insert) > maxTextOperationLength-target {
+			return nil, fmt.Errorf("文档长度不能超过 %d 个字符", maxTextOperationLength)
+		}
+		base += c.Retain + c.Delete
+		target += c.Retain + utf8.RuneCountInString(c.Insert)
+		b.add(c)
+	}
+	return b.operation(), nil
+}
+
+// textOpLengths 返回操作要求的文档长度与应用后的文档长度
+func textOpLengths(op model.TextOperation) (base, target int) {
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			base += c.Retain
+			target += c.Retain
+		case c.Insert != "":
+			target += utf8.RuneCountInString(c.Insert)
+		case c.Delete > 0:
+			base += c.Delete
+		}
+	}
+	return base, target
+}
+
+// applyTextOperation 将操作应用到文档
+func applyTextOperation(doc string, op model.TextOperation) (string, error) {
+	runes := []rune(doc)
+	if base, _ := textOpLengths(op); base != len(runes) {
+		return "", errTextOperationLength
+	}
+
+	out := make([]rune, 0, len(runes))
+	pos := 0
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			if c.Retain > len(runes)-pos {
+				return "", errTextOperationLength
+			}
+			out = append(out, runes[pos:pos+c.Retain]...)
+			pos += c.Retain
+		case c.Insert != "":
+			out = append(out, []rune(c.Insert)...)
+		case c.Delete > 0:
+			if c.Delete > len(runes)-pos {
+				return "", errTextOperationLength
+			}
+			pos += c.Delete
+		}
+	}
+	return string(out), nil
+}
+
+// transformTextOperations 变换两个基于同一文档的并发操作
+// 返回 (a', b')，满足 apply(apply(doc, a), b') == apply(apply(doc, b), a')；同一位置的插入 a 在前。
+func transformTextOperations(a, b model.TextOperation) (model.TextOperation, model.TextOperation, error) {
+	baseA, _ := textOpLengths(a)
+	baseB, _ := textOpLengths(b)
+	if baseA != baseB {
+		return nil, nil, errTextOperationLength
+	}
+
+	var ap, bp textOpBuilder
+	ia, ib := 0, 0
+	next := func(op model.TextOperation, i *int) *model.TextOpComponent {
+		if *i >= len(op) {
+			return nil
+		}
+		c := op[*i]
+		*i++
+		return &c
+	}
+	ca, cb := next(a, &ia), next(b, &ib)
+
+	for ca != nil || cb != nil {
+		if ca != nil && ca.Insert != "" {
+			ap.insert(ca.Insert)
+			bp.retain(utf8.RuneCountInString(ca.Insert))
+			ca = next(a, &ia)
+			continue
+		}
+		if cb != nil && cb.Insert != "" {
+			ap.retain(utf8.RuneCountInString(cb.Insert))
+			bp.insert(cb.Insert)
+			cb = next(b, &ib)
+			continue
+		}
+		if ca == nil || cb == nil {
+			return nil, nil, errTextOperationLength
+		}
+
+		lenA, lenB := ca.Retain+ca.Delete, cb.Retain+cb.Delete
+		n := min(lenA, lenB)
+		switch {
+		case ca.Retain > 0 && cb.Retain > 0:
+			ap.retain(n)
+			bp.retain(n)
+		case ca.Delete > 0 && cb.Retain > 0:
+			ap.delete(n)
+		case ca.Retain > 0 && cb.Delete > 0:
+			bp.delete(n)
+		}
+		// 双方都删除的部分无需输出
+
+		ca = consumeTextOp(ca, n, func() *model.TextOpComponent { return next(a, &ia) })
+		cb = consumeTextOp(cb, n, func() *model.TextOpComponent { return next(b, &ib) })
+	}
+	return ap.operation(), bp.operation(), nil
+}
+
+// consumeTextOp 从 retain / delete 分量中消耗 n 个字符，用完时取下一个分量
+func consumeTextOp(c *model.TextOpComponent, n int, next func() *model.TextOpComponent) *model.TextOpComponent {
+	if c.Retain > 0 {
+		c.Retain -= n
+		if c.Retain == 0 {
+			return next()
+		}
+		return c
+	}
+	c.Delete -= n
+	if c.Delete == 0 {
+		return next()
+	}
+	return c
+}
+
+// diffTextOperation 生成把 from 变为 to 的操作（保留公共前后缀，中间整体替换）
+func diffTextOperation(from, to string) model.TextOperation {
+	a, b := []rune(from), []rune(to)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var op textOpBuilder
+	op.retain(prefix)
+	op.insert(string(b[prefix : len(b)-suffix]))
+	op.delete(len(a) - prefix - suffix)
+	op.retain(suffix)
+	return op.operation()
+}
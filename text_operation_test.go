@@ -0,0 +1,245 @@
+package article
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+)
+
+func retainOp(n int) model.TextOpComponent    { return model.TextOpComponent{Retain: n} }
+func insertOp(s string) model.TextOpComponent { return model.TextOpComponent{Insert: s} }
+func deleteOp(n int) model.TextOpComponent    { return model.TextOpComponent{Delete: n} }
+
+func textOp(cs ...model.TextOpComponent) model.TextOperation { return model.TextOperation(cs) }
+
+func TestTransformTextOperationsConverges(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a, b model.TextOperation
+		want string
+	}{
+		{
+			name: "不同位置插入",
+			doc:  "hello",
+			a:    textOp(insertOp(">"), retainOp(5)),
+			b:    textOp(retainOp(5), insertOp("!")),
+			want: ">hello!",
+		},
+		{
+			name: "同一位置插入时 a 在前",
+			doc:  "ab",
+			a:    textOp(retainOp(1), insertOp("X"), retainOp(1)),
+			b:    textOp(retainOp(1), insertOp("Y"), retainOp(1)),
+			want: "aXYb",
+		},
+		{
+			name: "文档开头同时插入",
+			doc:  "",
+			a:    textOp(insertOp("甲")),
+			b:    textOp(insertOp("乙")),
+			want: "甲乙",
+		},
+		{
+			name: "删除区间部分重叠",
+			doc:  "abcdef",
+			a:    textOp(retainOp(1), deleteOp(3), retainOp(2)),
+			b:    textOp(retainOp(2), deleteOp(3), retainOp(1)),
+			want: "af",
+		},
+		{
+			name: "删除区间完全相同",
+			doc:  "abcdef",
+			a:    textOp(retainOp(2), deleteOp(2), retainOp(2)),
+			b:    textOp(retainOp(2), deleteOp(2), retainOp(2)),
+			want: "abef",
+		},
+		{
+			name: "一方删除区间包含另一方",
+			doc:  "abcdef",
+			a:    textOp(deleteOp(6)),
+			b:    textOp(retainOp(2), deleteOp(1), retainOp(3)),
+			want: "",
+		},
+		{
+			name: "在对方删除的区间内插入",
+			doc:  "abcdef",
+			a:    textOp(retainOp(1), deleteOp(4), retainOp(1)),
+			b:    textOp(retainOp(3), insertOp("XY"), retainOp(3)),
+			want: "aXYf",
+		},
+		{
+			name: "替换与相邻插入",
+			doc:  "abc",
+			a:    textOp(retainOp(1), insertOp("X"), deleteOp(1), retainOp(1)),
+			b:    textOp(retainOp(1), insertOp("Y"), retainOp(2)),
+			want: "aXYc",
+		},
+		{
+			name: "多字节字符按码点计算",
+			doc:  "你好世界",
+			a:    textOp(retainOp(2), insertOp("，"), retainOp(2)),
+			b:    textOp(retainOp(3), deleteOp(1)),
+			want: "你好，世",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap, bp, err := transformTextOperations(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("transform: %v", err)
+			}
+			left := mustApplyTextOps(t, tt.doc, tt.a, bp)
+			right := mustApplyTextOps(t, tt.doc, tt.b, ap)
+			if left != right {
+				t.Fatalf("结果不收敛: apply(a, b')=%q, apply(b, a')=%q", left, right)
+			}
+			if left != tt.want {
+				t.Fatalf("got %q, want %q", left, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformTextOperationsLengthMismatch(t *testing.T) {
+	_, _, err := transformTextOperations(textOp(retainOp(3)), textOp(retainOp(4)))
+	if !errors.Is(err, errTextOperationLength) {
+		t.Fatalf("got %v, want errTextOperationLength", err)
+	}
+}
+
+func TestApplyTextOperationLengthMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		op   model.TextOperation
+	}{
+		{"长度不足", "abc", textOp(retainOp(2))},
+		{"长度溢出后回绕", "", textOp(retainOp(math.MaxInt), insertOp("a"), retainOp(math.MaxInt), insertOp("b"), retainOp(2))},
+		{"删除越界", "ab", textOp(deleteOp(math.MaxInt), deleteOp(math.MaxInt), retainOp(4))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyTextOperation(tt.doc, tt.op); !errors.Is(err, errTextOperationLength) {
+				t.Fatalf("got %v, want errTextOperationLength", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeTextOperation(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      model.TextOperation
+		want    model.TextOperation
+		wantErr bool
+	}{
+		{
+			name: "合并相邻同类分量",
+			op:   textOp(retainOp(1), retainOp(2), insertOp("a"), insertOp("b")),
+			want: textOp(retainOp(3), insertOp("ab")),
+		},
+		{
+			name: "插入排在删除之前",
+			op:   textOp(retainOp(1), deleteOp(2), insertOp("x"), retainOp(1)),
+			want: textOp(retainOp(1), insertOp("x"), deleteOp(2), retainOp(1)),
+		},
+		{
+			name: "空操作",
+			op:   textOp(),
+			want: model.TextOperation{},
+		},
+		{
+			name:    "分量为空",
+			op:      textOp(model.TextOpComponent{}),
+			wantErr: true,
+		},
+		{
+			name:    "分量包含多种操作",
+			op:      textOp(model.TextOpComponent{Retain: 1, Insert: "a"}),
+			wantErr: true,
+		},
+		{
+			name:    "负数长度",
+			op:      textOp(deleteOp(-1)),
+			wantErr: true,
+		},
+		{
+			name:    "长度累加溢出",
+			op:      textOp(retainOp(math.MaxInt), insertOp("a"), retainOp(math.MaxInt), insertOp("b"), retainOp(2)),
+			wantErr: true,
+		},
+		{
+			name:    "删除长度超过上限",
+			op:      textOp(retainOp(1), deleteOp(maxTextOperationLength)),
+			wantErr: true,
+		},
+		{
+			name: "长度恰好等于上限",
+			op:   textOp(retainOp(maxTextOperationLength-1), deleteOp(1)),
+			want: textOp(retainOp(maxTextOperationLength-1), deleteOp(1)),
+		},
+		{
+			name:    "无效 UTF-8",
+			op:      textOp(insertOp("\xff")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTextOperation(tt.op)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffTextOperation(t *testing.T) {
+	tests := []struct{ from, to string }{
+		{"", ""},
+		{"", "abc"},
+		{"abc", ""},
+		{"abc", "abc"},
+		{"hello world", "hello, world"},
+		{"aaaa", "aa"},
+		{"你好世界", "你们好世界"},
+	}
+
+	for _, tt := range tests {
+		op := diffTextOperation(tt.from, tt.to)
+		got, err := applyTextOperation(tt.from, op)
+		if err != nil {
+			t.Fatalf("diff(%q, %q): %v", tt.from, tt.to, err)
+		}
+		if got != tt.to {
+			t.Fatalf("diff(%q, %q) 应用后得到 %q", tt.from, tt.to, got)
+		}
+	}
+}
+
+func mustApplyTextOps(t *testing.T, doc string, ops ...model.TextOperation) string {
+	t.Helper()
+	for _, op := range ops {
+		var err error
+		if doc, err = applyTextOperation(doc, op); err != nil {
+			t.Fatalf("apply %v: %v", op, err)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,125 @@
+package article
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories 仓储集合，事务内的所有写操作都通过同一个集合完成
+type Repositories struct {
+	Article          ArticleRepository
+	Markdown         MarkdownArticleRepository
+	RichText         RichTextArticleRepository
+	Table            TableArticleRepository
+	TableRow         TableArticleRowRepository
+	Revision         ArticleRevisionRepository              // 可选
+	StructureHistory TableArticleStructureHistoryRepository // 可选
+	Tag              TagRepository                          // 可选
+	Comment          ArticleCommentRepository               // 可选
+	Attachment       ArticleAttachmentRepository            // 可选
+	MarkdownOp       MarkdownOperationRepository            // 可选
+	Grant            ArticleGrantRepository                 // 可选
+	ShareLink        ArticleShareLinkRepository             // 可选
+	EditLock         ArticleEditLockRepository              // 可选
+}
+
+// TxManager 事务管理器
+type TxManager interface {
+	// WithinTx 在事务内执行 fn，fn 返回错误时整体回滚
+	WithinTx(ctx context.Context, fn func(ctx context.Context, repos *Repositories) error) error
+}
+
+// NewGORMRepositories 基于同一个 *gorm.DB（可以是事务）创建全部 GORM 仓储
+func NewGORMRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Article:          NewArticleGORMRepository(db),
+		Markdown:         NewMarkdownArticleGORMRepository(db),
+		RichText:         NewRichTextArticleGORMRepository(db),
+		Table:            NewTableArticleGORMRepository(db),
+		TableRow:         NewTableArticleRowGORMRepository(db),
+		Revision:         NewArticleRevisionGORMRepository(db),
+		StructureHistory: NewTableArticleStructureHistoryGORMRepository(db),
+		Tag:              NewTagGORMRepository(db),
+		Comment:          NewArticleCommentGORMRepository(db),
+		Attachment:       NewArticleAttachmentGORMRepository(db),
+		MarkdownOp:       NewMarkdownOperationGORMRepository(db),
+		Grant:            NewArticleGrantGORMRepository(db),
+		ShareLink:        NewArticleShareLinkGORMRepository(db),
+		EditLock:         NewArticleEditLockGORMRepository(db),
+	}
+}
+
+// GORMTxManager GORM 事务管理器
+type GORMTxManager struct {
+	db *gorm.DB
+}
+
+func NewGORMTxManager(db *gorm.DB) *GORMTxManager {
+	return &GORMTxManager{db: db}
+}
+
+func (m *GORMTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, repos *Repositories) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ctx, NewGORMRepositories(tx))
+	})
+}
+
+// repositories 返回服务自身持有的仓储集合（非事务）
+func (s *Service) repositories() *Repositories {
+	return &Repositories{
+		Article:          s.articleRepo,
+		Markdown:         s.markdownRepo,
+		RichText:         s.richTextRepo,
+		Table:            s.tableRepo,
+		TableRow:         s.tableRowRepo,
+		Revision:         s.revisionRepo,
+		StructureHistory: s.historyRepo,
+		Tag:              s.tagRepo,
+		Comment:          s.commentRepo,
+		Attachment:       s.attachmentRepo,
+		MarkdownOp:       s.markdownOpRepo,
+		Grant:            s.grantRepo,
+		ShareLink:        s.shareLinkRepo,
+		EditLock:         s.editLockRepo,
+	}
+}
+
+// inTx 在事务内执行 fn；未注入 TxManager 时直接使用服务自身的仓储顺序执行
+// 事件应在 inTx 返回成功后再分发，避免回滚后仍然发出事件。
+func (s *Service) inTx(ctx context.Context, fn func(ctx context.Context, repos *Repositories) error) error {
+	if s.txManager == nil {
+		return fn(ctx, s.repositories())
+	}
+	return s.txManager.WithinTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		// 事务仓储集合只保留服务已启用的可选仓储
+		if s.revisionRepo == nil {
+			repos.Revision = nil
+		}
+		if s.historyRepo == nil {
+			repos.StructureHistory = nil
+		}
+		if s.tagRepo == nil {
+			repos.Tag = nil
+		}
+		if s.commentRepo == nil {
+			repos.Comment = nil
+		}
+		if s.attachmentRepo == nil {
+			repos.Attachment = nil
+		}
+		if s.markdownOpRepo == nil {
+			repos.MarkdownOp = nil
+		}
+		if s.grantRepo == nil {
+			repos.Grant = nil
+		}
+		if s.shareLinkRepo == nil {
+			repos.ShareLink = nil
+		}
+		if s.editLockRepo == nil {
+			repos.EditLock = nil
+		}
+		return fn(ctx, repos)
+	})
+}
@@ -0,0 +1,208 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ==================== 回收站 ====================
+//
+// DeleteArticle 只把文章移入回收站，内容、附件与授权等都保留，RestoreArticle 可以原样恢复；
+// PurgeArticle / PurgeTrashOlderThan 在同一事务内物理删除文章及其全部关联数据。
+
+// purgeBatch 批量清理回收站时每批处理的文章数
+const purgeBatch = 100
+
+// ListTrash 分页查询回收站中的文章，默认按删除时间倒序
+// 只使用 q 的筛选、排序与分页条件，状态固定为已删除；启用访问控制时只返回当前主体可见的文章。
+func (s *Service) ListTrash(ctx context.Context, q *ArticleQuery) (*PageResult, error) {
+	trash := *q
+	trash.Statuses = []int{model.StatusDeleted}
+	if trash.SortBy == "" {
+		trash.SortBy = SortByDeletedAt
+	}
+	return s.ListArticles(ctx, &trash)
+}
+
+// RestoreArticle 从回收站恢复文章（需要 owner），还原删除前的状态；文件夹不随删除变化，恢复后仍在原文件夹
+func (s *Service) RestoreArticle(ctx context.Context, id uint) (*model.Article, error) {
+	article, err := s.getTrashedArticleFor(ctx, id, model.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	article.Status = model.StatusDraft
+	if article.PrevStatus != nil && *article.PrevStatus != model.StatusDeleted {
+		article.Status = *article.PrevStatus
+	}
+	article.PrevStatus = nil
+	article.DeletedAt = nil
+	article.UpdatedAt = time.Now()
+	if err := s.articleRepo.Restore(ctx, article); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return nil, ErrBadRequest.WithMsg("文章不在回收站中")
+		}
+		s.logger.ErrorCtx(ctx, "恢复文章失败", zap.Uint("article_id", id), zap.Error(err))
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+
+	s.logger.InfoCtx(ctx, "文章恢复成功", zap.Uint("article_id", id))
+	s.dispatchAsync(ctx, NewArticleRestoredEvent(id, article.FolderID))
+	s.indexArticle(ctx, id)
+	return article, nil
+}
+
+// PurgeArticle 彻底删除回收站中的文章（需要 owner 及 TxManager），不可恢复
+func (s *Service) PurgeArticle(ctx context.Context, id uint) error {
+	if err := s.requireTx(); err != nil {
+		return err
+	}
+	article, err := s.getTrashedArticleFor(ctx, id, model.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := s.purgeArticle(ctx, article); err != nil {
+		if errors.Is(err, ErrStaleVersion) {
+			return ErrConflict.WithMsg("文章已被恢复")
+		}
+		return err
+	}
+	return nil
+}
+
+// PurgeTrashOlderThan 彻底删除移入回收站超过 age 的文章，返回删除数量
+// 供定时任务调用，不做权限校验（需要 TxManager）；期间被恢复的文章跳过，单篇失败时记录日志并继续，最后返回遇到的第一个错误。
+func (s *Service) PurgeTrashOlderThan(ctx context.Context, age time.Duration) (int, error) {
+	if err := s.requireTx(); err != nil {
+		return 0, err
+	}
+	before := time.Now().Add(-age)
+	var purged int
+	var firstErr error
+	var afterID uint
+	for {
+		articles, err := s.articleRepo.FindTrashedBefore(ctx, before, afterID, purgeBatch)
+		if err != nil {
+			s.logger.ErrorCtx(ctx, "查询回收站失败", zap.Error(err))
+			return purged, ErrDatabaseError.Wrap(err)
+		}
+
+		for i := range articles {
+			afterID = articles[i].ID
+			if err := s.purgeArticle(ctx, &articles[i]); err != nil {
+				if errors.Is(err, ErrStaleVersion) {
+					continue
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			purged++
+		}
+
+		if len(articles) < purgeBatch {
+			return purged, firstErr
+		}
+	}
+}
+
+// purgeArticle 在同一事务内删除文章及其内容、行数据、历史、标签、评论、附件、分享链接、授权与编辑锁
+// 先以"仍在回收站"为条件删除主表记录，文章在此期间已被恢复时放弃删除并原样返回 ErrStaleVersion；
+// 不再被引用的附件 Blob 在提交后从存储中删除。
+func (s *Service) purgeArticle(ctx context.Context, article *model.Article) error {
+	id := article.ID
+	var orphanKeys []string
+	err := s.inTx(ctx, func(ctx context.Context, repos *Repositories) error {
+		if err := repos.Article.HardDelete(ctx, id); err != nil {
+			if errors.Is(err, ErrStaleVersion) {
+				return err
+			}
+			return ErrDatabaseError.Wrap(err)
+		}
+
+		deletes := []func() error{
+			func() error { return repos.Markdown.DeleteByArticleID(ctx, id) },
+			func() error { return repos.RichText.DeleteByArticleID(ctx, id) },
+			func() error { return repos.Table.DeleteByArticleID(ctx, id) },
+			func() error { return repos.TableRow.DeleteByArticleID(ctx, id) },
+		}
+		if repos.Revision != nil {
+			deletes = append(deletes, func() error { return repos.Revision.DeleteByArticleID(ctx, id) })
+		}
+		if repos.StructureHistory != nil {
+			deletes = append(deletes, func() error { return repos.StructureHistory.DeleteByArticleID(ctx, id) })
+		}
+		if repos.MarkdownOp != nil {
+			deletes = append(deletes, func() error { return repos.MarkdownOp.DeleteByArticleID(ctx, id) })
+		}
+		if repos.Tag != nil {
+			deletes = append(deletes, func() error { return repos.Tag.DeleteArticleTagsByArticleID(ctx, id) })
+		}
+		if repos.Comment != nil {
+			deletes = append(deletes, func() error { return repos.Comment.DeleteByArticleID(ctx, id) })
+		}
+		if repos.ShareLink != nil {
+			deletes = append(deletes, func() error { return repos.ShareLink.DeleteByArticleID(ctx, id) })
+		}
+		if repos.Grant != nil {
+			deletes = append(deletes, func() error { return repos.Grant.DeleteByResource(ctx, model.ResourceTypeArticle, id) })
+		}
+		if repos.EditLock != nil {
+			deletes = append(deletes, func() error { return repos.EditLock.DeleteByArticleID(ctx, id) })
+		}
+		for _, del := range deletes {
+			if err := del(); err != nil {
+				return ErrDatabaseError.Wrap(err)
+			}
+		}
+
+		var err error
+		orphanKeys, err = releaseArticleAttachments(ctx, repos, id)
+		return err
+	})
+	if errors.Is(err, ErrStaleVersion) {
+		s.logger.InfoCtx(ctx, "文章已不在回收站，跳过彻底删除", zap.Uint("article_id", id))
+		return err
+	}
+	if err != nil {
+		s.logger.ErrorCtx(ctx, "彻底删除文章失败", zap.Uint("article_id", id), zap.Error(err))
+		return err
+	}
+	s.deleteBlobs(ctx, orphanKeys)
+
+	s.logger.InfoCtx(ctx, "文章已彻底删除", zap.Uint("article_id", id))
+	s.dispatchAsync(ctx, NewArticlePurgedEvent(id, article.FolderID))
+	return nil
+}
+
+// requireTx 彻底删除涉及十余张表，必须在同一事务内执行，未注入 TxManager 时拒绝
+func (s *Service) requireTx() error {
+	if s.txManager == nil {
+		return ErrBadRequest.WithMsg("未配置事务管理器，不能彻底删除文章")
+	}
+	return nil
+}
+
+// getTrashedArticleFor 获取回收站中的文章并校验权限
+func (s *Service) getTrashedArticleFor(ctx context.Context, id uint, minRole string) (*model.Article, error) {
+	article, err := s.articleRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("文章不存在")
+		}
+		return nil, ErrDatabaseError.Wrap(err)
+	}
+	if !article.IsDeleted() {
+		return nil, ErrBadRequest.WithMsg("文章不在回收站中")
+	}
+	if err := s.authorize(ctx, article, minRole); err != nil {
+		return nil, err
+	}
+	return article, nil
+}
@@ -0,0 +1,488 @@
+package article
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 最小化的 XLSX（Office Open XML）读写，只处理单元格值，不处理公式、合并单元格与样式。
+
+const (
+	xlsxMainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	xlsxMaxColumns = 16384 // Excel 列数上限（XFD）
+)
+
+// ==================== 读取 ====================
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t *xlsxRichText) text() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string        `xml:"r,attr"`
+		Type   string        `xml:"t,attr"`
+		Style  int           `xml:"s,attr"`
+		Value  string        `xml:"v"`
+		Inline *xlsxRichText `xml:"is"`
+	} `xml:"c"`
+}
+
+// xlsxReader 读取工作表单元格为字符串
+type xlsxReader struct {
+	zr        *zip.Reader
+	shared    []string
+	dateStyle map[int]bool // cellXfs 下标 -> 是否为日期格式
+	date1904  bool
+	sheets    map[string]string // 工作表名 -> 文件路径
+	order     []string          // 工作表顺序
+	remaining int64             // 剩余可解压的字节数，各部件共用
+}
+
+// newXLSXReader maxSize 为各部件解压后的总字节数上限，防止压缩炸弹
+func newXLSXReader(r io.ReaderAt, size, maxSize int64) (*xlsxReader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxReader{zr: zr, sheets: make(map[string]string), dateStyle: make(map[int]bool), remaining: maxSize}
+
+	var wb xlsxWorkbook
+	if err := x.decode("xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := x.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+	for _, s := range wb.Sheets {
+		if target, ok := targets[s.RID]; ok {
+			x.sheets[s.Name] = target
+			x.order = append(x.order, s.Name)
+		}
+	}
+	x.date1904 = wb.WorkbookPr.Date1904
+
+	// 共享字符串与样式均为可选部件
+	var sst xlsxSharedStrings
+	if err := x.decode("xl/sharedStrings.xml", &sst); err != nil && !errors.Is(err, errXLSXPartMissing) {
+		return nil, err
+	}
+	for i := range sst.Items {
+		x.shared = append(x.shared, sst.Items[i].text())
+	}
+	var styles xlsxStyles
+	if err := x.decode("xl/styles.xml", &styles); err != nil && !errors.Is(err, errXLSXPartMissing) {
+		return nil, err
+	}
+	custom := make(map[int]string, len(styles.NumFmts))
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	for i, xf := range styles.CellXfs {
+		x.dateStyle[i] = isDateNumFmt(xf.NumFmtID, custom[xf.NumFmtID])
+	}
+	return x, nil
+}
+
+var (
+	errXLSXPartMissing = errors.New("xlsx part missing")
+	errXLSXTooLarge    = errors.New("xlsx uncompressed size exceeds limit")
+)
+
+func (x *xlsxReader) open(name string) (io.ReadCloser, error) {
+	for _, f := range x.zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			return &xlsxBudgetReader{ReadCloser: rc, remaining: &x.remaining}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errXLSXPartMissing, name)
+}
+
+// xlsxBudgetReader 从共用额度中扣除读取的字节数，超出时返回 errXLSXTooLarge
+type xlsxBudgetReader struct {
+	io.ReadCloser
+	remaining *int64
+}
+
+func (r *xlsxBudgetReader) Read(p []byte) (int, error) {
+	// 多读一个字节用于判断是否超出额度
+	if int64(len(p)) > *r.remaining+1 {
+		p = p[:*r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	*r.remaining -= int64(n)
+	if *r.remaining < 0 {
+		return n, errXLSXTooLarge
+	}
+	return n, err
+}
+
+func (x *xlsxReader) decode(name string, v any) error {
+	rc, err := x.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// readSheet 逐行读取工作表（sheet 为空时读取第一个），fn 收到的行已按列号补齐空单元格
+// 单元格列号不小于 maxColumns 时返回 errTooManyImportColumns，避免按列号补齐时分配过大的行。
+func (x *xlsxReader) readSheet(sheet string, maxColumns int, fn func(cells []string) error) error {
+	if sheet == "" {
+		if len(x.order) == 0 {
+			return errors.New("workbook has no sheets")
+		}
+		sheet = x.order[0]
+	}
+	name, ok := x.sheets[sheet]
+	if !ok {
+		return fmt.Errorf("sheet not found: %s", sheet)
+	}
+	rc, err := x.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return err
+		}
+
+		var cells []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return err
+				}
+			}
+			if col >= maxColumns {
+				return errTooManyImportColumns
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			cells[col] = x.cellValue(c.Type, c.Style, c.Value, c.Inline)
+		}
+		if err := fn(cells); err != nil {
+			return err
+		}
+	}
+}
+
+func (x *xlsxReader) cellValue(typ string, style int, value string, inline *xlsxRichText) string {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(x.shared) {
+			return ""
+		}
+		return x.shared[i]
+	case "inlineStr":
+		if inline == nil {
+			return ""
+		}
+		return inline.text()
+	case "b":
+		if value == "1" {
+			return "true"
+		}
+		return "false"
+	case "e":
+		return ""
+	case "str":
+		return value
+	}
+	if value != "" && x.dateStyle[style] {
+		if serial, err := strconv.ParseFloat(value, 64); err == nil {
+			return excelSerialToString(serial, x.date1904)
+		}
+	}
+	return value
+}
+
+// isDateNumFmt 数字格式是否为日期/时间（内置格式按 ID 判断，自定义格式按格式串判断）
+func isDateNumFmt(id int, code string) bool {
+	switch {
+	case id >= 14 && id <= 22, id >= 27 && id <= 36, id >= 45 && id <= 47, id >= 50 && id <= 58:
+		return true
+	case code == "":
+		return false
+	}
+	// 去掉引号内的字面量和 [Red] 之类的修饰
+	var b strings.Builder
+	inQuote, inBracket := false, false
+	for _, r := range strings.ToLower(code) {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[':
+			inBracket = true
+		case r == ']':
+			inBracket = false
+		case inBracket:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	cleaned := b.String()
+	return cleaned != "general" && strings.ContainsAny(cleaned, "ydhms")
+}
+
+// excelSerialToString Excel 序列日期转为 2006-01-02 或 2006-01-02 15:04:05
+func excelSerialToString(serial float64, date1904 bool) string {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		base = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t := base.Add(time.Duration(math.Round(serial*86400)) * time.Second)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.DateTime)
+}
+
+// columnIndex 单元格引用（如 "AB12"）转为从 0 开始的列号
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		// 超过上限立即返回，避免过长的列名溢出为负数
+		col = col*26 + int(r-'A'+1)
+		n++
+		if col > xlsxMaxColumns {
+			return 0, fmt.Errorf("invalid cell reference: %s", ref)
+		}
+	}
+	if n == 0 || col <= 0 {
+		return 0, fmt.Errorf("invalid cell reference: %s", ref)
+	}
+	return col - 1, nil
+}
+
+// columnName 从 0 开始的列号转为列名（0 -> A, 26 -> AA）
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// ==================== 写入 ====================
+
+// xlsxWriter 流式写出单工作表 XLSX，字符串使用内联字符串以免缓存共享字符串表
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+func newXLSXWriter(w io.Writer, sheetName string) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + xlsxMainNS + `" xmlns:r="` + xlsxRelNS + `">` +
+			`<sheets><sheet name="` + xmlEscape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		// 样式 1 为加粗，用于表头
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + xlsxMainNS + `">` +
+			`<fonts count="2"><font/><font><b/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border/></borders>` +
+			`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="` + xlsxMainNS + `"><sheetData>`)
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow 写入一行；支持字符串、数值与布尔值，其他类型由调用方预先格式化
+func (x *xlsxWriter) WriteRow(values []any, bold bool) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	style := ""
+	if bold {
+		style = ` s="1"`
+	}
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.rows)
+		switch val := v.(type) {
+		case nil:
+			continue
+		case bool:
+			n := 0
+			if val {
+				n = 1
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, style, n)
+		case int64:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%d</v></c>`, ref, style, val)
+		case float64:
+			if math.IsNaN(val) || math.IsInf(val, 0) {
+				continue
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(val, 'f', -1, 64))
+		default:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(fmt.Sprint(val)))
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close 结束工作表并写出 zip 目录
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxSheetName 工作表名最长 31 个字符且不能包含 []:*?/\
+func xlsxSheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
@@ -0,0 +1,290 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-article/model"
+	"github.com/KOMKZ/go-yogan-framework/logger"
+	"gorm.io/gorm"
+)
+
+// 以下内存仓储只实现导入导出用到的方法，其余方法调用时 panic
+
+type memArticleRepo struct {
+	ArticleRepository
+	articles map[uint]*model.Article
+}
+
+func (r *memArticleRepo) Create(_ context.Context, article *model.Article) error {
+	article.ID = uint(len(r.articles) + 1)
+	saved := *article
+	r.articles[article.ID] = &saved
+	return nil
+}
+
+func (r *memArticleRepo) FindByID(_ context.Context, id uint) (*model.Article, error) {
+	article, ok := r.articles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *article
+	return &found, nil
+}
+
+type memTableRepo struct {
+	TableArticleRepository
+	tables map[uint]*model.TableArticle
+}
+
+func (r *memTableRepo) Create(_ context.Context, table *model.TableArticle) error {
+	saved := *table
+	r.tables[table.ArticleID] = &saved
+	return nil
+}
+
+func (r *memTableRepo) FindByArticleID(_ context.Context, articleID uint) (*model.TableArticle, error) {
+	table, ok := r.tables[articleID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *table
+	return &found, nil
+}
+
+type memTableRowRepo struct {
+	TableArticleRowRepository
+	rows map[uint][]model.TableArticleRow
+}
+
+func (r *memTableRowRepo) BatchCreate(_ context.Context, rows []model.TableArticleRow) error {
+	for _, row := range rows {
+		r.rows[row.ArticleID] = append(r.rows[row.ArticleID], row)
+	}
+	return nil
+}
+
+func (r *memTableRowRepo) FindBatch(_ context.Context, articleID uint, offset, limit int) ([]model.TableArticleRow, error) {
+	rows := r.rows[articleID]
+	if offset >= len(rows) {
+		return nil, nil
+	}
+	return append([]model.TableArticleRow(nil), rows[offset:min(offset+limit, len(rows))]...), nil
+}
+
+func newTableIOTestService() *Service {
+	return NewService(
+		&memArticleRepo{articles: make(map[uint]*model.Article)},
+		nil,
+		nil,
+		&memTableRepo{tables: make(map[uint]*model.TableArticle)},
+		&memTableRowRepo{rows: make(map[uint][]model.TableArticleRow)},
+		logger.GetLogger("yogan"),
+	)
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		structure   []map[string]interface{}
+		columnOrder []string
+		data        []map[string]interface{}
+		wantTypes   []string // 重新导入后按导出列顺序推断出的列类型
+	}{
+		{
+			name: "各类型列",
+			structure: []map[string]interface{}{
+				{"field": "name", "title": "名称", "type": "text"},
+				{"field": "qty", "title": "数量", "type": "integer"},
+				{"field": "price", "title": "单价", "type": "number"},
+				{"field": "active", "title": "启用", "type": "boolean"},
+				{"field": "day", "title": "日期", "type": "date"},
+				{"field": "total", "title": "合计", "type": "formula", "formula": "price * qty"},
+			},
+			data: []map[string]interface{}{
+				{"name": "苹果", "qty": 3, "price": 2.5, "active": true, "day": "2024-01-02"},
+				{"name": "香蕉", "qty": 10, "price": 0.75, "active": false, "day": "2024-12-31"},
+				{"name": "橙子", "qty": -1, "price": 1e-3, "active": true, "day": "2000-02-29"},
+			},
+			wantTypes: []string{"text", "integer", "number", "boolean", "date", "number"},
+		},
+		{
+			name: "按列顺序导出",
+			structure: []map[string]interface{}{
+				{"field": "a", "title": "A", "type": "text"},
+				{"field": "b", "title": "B", "type": "integer"},
+				{"field": "c", "title": "C", "type": "text"},
+			},
+			columnOrder: []string{"c", "a"},
+			data: []map[string]interface{}{
+				{"a": "x", "b": 1, "c": "z"},
+				{"a": "y", "b": 2, "c": "w"},
+			},
+			wantTypes: []string{"text", "text", "integer"},
+		},
+		{
+			name: "特殊文本与空单元格",
+			structure: []map[string]interface{}{
+				{"field": "text", "title": "<标题 & \"引号\">", "type": "text"},
+				{"field": "code", "title": "编号", "type": "text"},
+				{"field": "note", "title": "备注", "type": "text"},
+			},
+			data: []map[string]interface{}{
+				{"text": "中间 空格", "code": "007", "note": "第一行\n第二行"},
+				{"text": "a<b>&c", "code": "0012"},
+				{"code": "42", "note": "]]>"},
+			},
+			wantTypes: []string{"text", "text", "text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := newTableIOTestService()
+
+			source, err := svc.CreateTableArticle(ctx, &CreateTableArticleInput{
+				Title:       tt.name,
+				TableID:     newTableID(),
+				OwnerID:     1,
+				OwnerType:   "user",
+				Structure:   tt.structure,
+				ColumnOrder: tt.columnOrder,
+				Data:        tt.data,
+			})
+			if err != nil {
+				t.Fatalf("CreateTableArticle: %v", err)
+			}
+
+			var xlsx bytes.Buffer
+			if err := svc.ExportTableAsXLSX(ctx, source.ID, &xlsx); err != nil {
+				t.Fatalf("ExportTableAsXLSX: %v", err)
+			}
+			imported, err := svc.ImportTableFromXLSX(ctx, &ImportTableInput{
+				Title:     tt.name + " 导入",
+				OwnerID:   1,
+				OwnerType: "user",
+			}, bytes.NewReader(xlsx.Bytes()), int64(xlsx.Len()))
+			if err != nil {
+				t.Fatalf("ImportTableFromXLSX: %v", err)
+			}
+
+			table, err := svc.tableRepo.FindByArticleID(ctx, imported.ID)
+			if err != nil {
+				t.Fatalf("FindByArticleID: %v", err)
+			}
+			var gotTypes []string
+			for _, col := range orderedColumns(table) {
+				gotTypes = append(gotTypes, col.Type)
+			}
+			if strings.Join(gotTypes, ",") != strings.Join(tt.wantTypes, ",") {
+				t.Errorf("列类型 = %v, want %v", gotTypes, tt.wantTypes)
+			}
+
+			// 两张表导出的表头与单元格文本应当一致
+			want := exportCSVText(t, svc, source.ID)
+			if got := exportCSVText(t, svc, imported.ID); got != want {
+				t.Errorf("往返后内容不一致\n got: %q\nwant: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestImportTableFromXLSXLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]any
+		raw     string // 直接写入 sheetData 的行，用于构造写入器不会生成的单元格引用
+		wantMsg string
+	}{
+		{
+			name:    "空工作表",
+			rows:    nil,
+			wantMsg: "导入文件为空",
+		},
+		{
+			name:    "列数超过上限",
+			rows:    [][]any{make([]any, MaxImportColumns+1)},
+			wantMsg: "导入列数不能超过",
+		},
+		{
+			name:    "列引用超过 Excel 上限",
+			raw:     `<row r="1"><c r="XFE1" t="inlineStr"><is><t>h</t></is></c></row>`,
+			wantMsg: "invalid cell reference",
+		},
+		{
+			name:    "过长的列引用不会溢出",
+			raw:     `<row r="1"><c r="AAAAAAAAAAAAAAA1" t="inlineStr"><is><t>h</t></is></c></row>`,
+			wantMsg: "invalid cell reference",
+		},
+		{
+			name:    "列引用缺少列名",
+			raw:     `<row r="1"><c r="1" t="inlineStr"><is><t>h</t></is></c></row>`,
+			wantMsg: "invalid cell reference",
+		},
+	}
+	for i := range tests[1].rows[0] {
+		tests[1].rows[0][i] = "h"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := newXLSXWriter(&buf, "Sheet1")
+			if err != nil {
+				t.Fatalf("newXLSXWriter: %v", err)
+			}
+			for _, row := range tt.rows {
+				if err := w.WriteRow(row, false); err != nil {
+					t.Fatalf("WriteRow: %v", err)
+				}
+			}
+			w.sheet.WriteString(tt.raw)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			_, err = newTableIOTestService().ImportTableFromXLSX(context.Background(), &ImportTableInput{Title: tt.name},
+				bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("got %v, want error containing %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestXLSXReaderSizeBudget(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newXLSXWriter(&buf, "Sheet1")
+	if err != nil {
+		t.Fatalf("newXLSXWriter: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := w.WriteRow([]any{strings.Repeat("x", 100), int64(i)}, false); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	x, err := newXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 4096)
+	if err == nil {
+		err = x.readSheet("", MaxImportColumns, func([]string) error { return nil })
+	}
+	if !errors.Is(err, errXLSXTooLarge) {
+		t.Fatalf("got %v, want errXLSXTooLarge", err)
+	}
+}
+
+func exportCSVText(t *testing.T, svc *Service, articleID uint) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := svc.ExportTableAsCSV(context.Background(), articleID, &buf); err != nil {
+		t.Fatalf("ExportTableAsCSV: %v", err)
+	}
+	return buf.String()
+}